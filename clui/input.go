@@ -3,21 +3,35 @@ package clui
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"strings"
 
 	"github.com/bit101/go-ansi"
-	"github.com/bit101/tinfox/config"
 	"github.com/bit101/tinfox/theme"
+	"golang.org/x/term"
 )
 
+// reader is shared by all prompts so that input buffered by one prompt is not lost to the next.
+var reader = bufio.NewReader(os.Stdin)
+
+// IsInteractive reports whether stdin is a terminal that can be prompted.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// canReadRaw reports whether input can be read from the terminal directly, bypassing reader.
+// Once earlier prompts have buffered input that was typed ahead or pasted, it must be read from reader.
+func canReadRaw() bool {
+	return IsInteractive() && reader.Buffered() == 0
+}
+
 // ReadStringDefault displays a prompt and collects input.
 func ReadStringDefault(prompt, def string) string {
 	ansi.Printf(theme.Instruction, "%s ", prompt)
 	if def != "" {
 		ansi.Printf(theme.Default, "(%s) ", def)
 	}
-	reader := bufio.NewReader(os.Stdin)
 	str, _ := reader.ReadString('\n')
 	str = strings.TrimSuffix(str, "\n")
 	if str == "" {
@@ -29,7 +43,6 @@ func ReadStringDefault(prompt, def string) string {
 // ReadString displays a prompt and collects input.
 func ReadString(prompt string) string {
 	ansi.Printf(theme.Instruction, "%s ", prompt)
-	reader := bufio.NewReader(os.Stdin)
 	str, err := reader.ReadString('\n')
 	if err != nil {
 		return ""
@@ -37,36 +50,134 @@ func ReadString(prompt string) string {
 	return strings.TrimSuffix(str, "\n")
 }
 
-// ReadToken displays a prompt and collects input.
-func ReadToken(prompt, defaultValue string, isRequired, isPath bool) string {
-	ok := false
-	var value string
-	for !ok {
-		ok = true
+// ReadToken displays a prompt and collects input, prompting again until check accepts the value.
+// If help is not empty, entering ? shows it. Any suggestions are shown and can be completed with tab.
+func ReadToken(prompt, defaultValue, help string, suggestions []string, check func(value string) error) string {
+	if len(suggestions) > 0 {
+		showSuggestions(suggestions)
+	}
+	return readChecked(help, check, func() string {
+		if len(suggestions) > 0 {
+			return readSuggested(prompt, defaultValue, suggestions)
+		}
 		if defaultValue == "" {
-			value = ReadString(prompt)
-		} else {
-			value = ReadStringDefault(prompt, defaultValue)
+			return ReadString(prompt)
+		}
+		return ReadStringDefault(prompt, defaultValue)
+	})
+}
+
+// ReadSecretToken is like ReadToken, but the input is not echoed and the default value is not shown.
+func ReadSecretToken(prompt, defaultValue, help string, check func(value string) error) string {
+	return readChecked(help, check, func() string {
+		ansi.Printf(theme.Instruction, "%s ", prompt)
+		if defaultValue != "" {
+			ansi.Print(theme.Default, "(hidden default) ")
 		}
-		value = strings.TrimSpace(value)
-		if isRequired && value == "" {
-			theme.PrintErrorln("Value cannot be empty.")
-			ok = false
+		value := readHidden()
+		if value == "" {
+			return defaultValue
+		}
+		return value
+	})
+}
+
+// readHidden reads a line without echoing it when stdin is a terminal.
+func readHidden() string {
+	if !canReadRaw() {
+		str, _ := reader.ReadString('\n')
+		return strings.TrimSuffix(str, "\n")
+	}
+	b, _ := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	return string(b)
+}
+
+// readChecked calls read until check accepts the trimmed value, showing each error, or the help for ?.
+func readChecked(help string, check func(value string) error, read func() string) string {
+	for {
+		value := strings.TrimSpace(read())
+		if value == "?" && help != "" {
+			ShowHelp(help)
 			continue
 		}
-		if isPath {
-			if value == "" {
-				theme.PrintErrorln("Path cannot be empty.")
-				ok = false
+		err := check(value)
+		if err == nil {
+			return value
+		}
+		msg := err.Error()
+		msg = strings.ToUpper(msg[:1]) + msg[1:]
+		if !strings.HasSuffix(msg, ".") {
+			msg += "."
+		}
+		theme.PrintErrorln(msg)
+	}
+}
+
+// ReadBool displays a yes/no prompt and collects the answer. An empty answer chooses the default.
+// If help is not empty, entering ? shows it.
+func ReadBool(prompt string, def bool, help string) bool {
+	options := "[y/N]"
+	if def {
+		options = "[Y/n]"
+	}
+	for {
+		value := strings.ToLower(strings.TrimSpace(ReadString(prompt + " " + options)))
+		switch value {
+		case "":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "?":
+			if help != "" {
+				ShowHelp(help)
+				continue
 			}
-			for _, c := range config.ActiveConfig.InvalidPathChars {
-				if strings.Contains(value, string(c)) {
-					theme.PrintErrorf("Path cannot contain %q\n", string(c))
-					ok = false
-					break
-				}
+		}
+		theme.PrintErrorln("Please answer y or n.")
+	}
+}
+
+// ReadList collects a list of items, entered either comma separated on one line,
+// or one per line and ended with an empty line. Entering nothing chooses the default.
+// If help is not empty, entering ? as the first item shows it.
+func ReadList(prompt string, def []string, help string) []string {
+	ansi.Printf(theme.Instruction, "%s ", prompt)
+	if len(def) > 0 {
+		ansi.Printf(theme.Default, "(%s) ", strings.Join(def, ", "))
+	}
+	fmt.Println()
+	items := []string{}
+	for {
+		line := strings.TrimSpace(ReadString("  >"))
+		if line == "" {
+			break
+		}
+		if line == "?" && help != "" && len(items) == 0 {
+			ShowHelp(help)
+			continue
+		}
+		for _, item := range strings.Split(line, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				items = append(items, item)
 			}
 		}
+		if strings.Contains(line, ",") {
+			break
+		}
+	}
+	if len(items) == 0 {
+		return def
+	}
+	return items
+}
+
+// ShowHelp displays the help text for a prompt.
+func ShowHelp(help string) {
+	for _, line := range strings.Split(help, "\n") {
+		ansi.Println(theme.Default, "  "+line)
 	}
-	return value
 }
@@ -2,7 +2,6 @@
 package clui
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strconv"
@@ -14,21 +13,31 @@ import (
 
 // MultiChoice presents a multichoice menu
 func MultiChoice(choices []string, instructions string) (int, string) {
+	return MultiChoiceDefault(choices, instructions, -1, "")
+}
+
+// MultiChoiceDefault presents a multichoice menu in which an empty response chooses the default index.
+// A default index of -1 means there is no default. If help is not empty, entering ? shows it above the menu.
+func MultiChoiceDefault(choices []string, instructions string, def int, help string) (int, string) {
 	var choice int
 	count := len(choices)
 	errStr := ""
+	showHelp := false
 
-	for i := 0; i < count+4; i++ {
+	// leave room for the help so that showing it does not scroll the menu.
+	helpLines := 0
+	if help != "" {
+		helpLines = strings.Count(help, "\n") + 1
+	}
+	for i := 0; i < count+4+helpLines; i++ {
 		fmt.Println()
 	}
-	ansi.MoveUp(count + 4)
+	ansi.MoveUp(count + 4 + helpLines)
 	ansi.Save()
 
-	reader := bufio.NewReader(os.Stdin)
-
 	ok := false
 	for ok == false {
-		outputMultiChoice(choices, instructions, errStr)
+		outputMultiChoice(choices, instructions, errStr, def, showHelp, help)
 		ok = true
 		errStr = ""
 
@@ -45,6 +54,19 @@ func MultiChoice(choices []string, instructions string) (int, string) {
 			os.Exit(0)
 		}
 
+		// help?
+		if input == "?" && help != "" {
+			showHelp = true
+			ok = false
+			continue
+		}
+
+		// default?
+		if input == "" && def >= 0 && def < count {
+			choice = def + 1
+			break
+		}
+
 		// parse int
 		choice64, err := strconv.ParseInt(input, 10, 32)
 		if err != nil {
@@ -66,18 +88,30 @@ func MultiChoice(choices []string, instructions string) (int, string) {
 	return choice - 1, result
 }
 
-func outputMultiChoice(choices []string, instructions, errStr string) {
+func outputMultiChoice(choices []string, instructions, errStr string, def int, showHelp bool, help string) {
 	ansi.Restore()
 	ansi.ClearToEnd()
+	if showHelp {
+		for _, line := range strings.Split(help, "\n") {
+			ansi.Print(theme.Default, "  "+line+"\r\n")
+		}
+	}
 	if errStr != "" {
 		ansi.Print(theme.Error, errStr)
 	}
 	ansi.Println(theme.Header, instructions, "\r")
 
 	for i := 0; i < len(choices); i++ {
-		fmt.Printf("%d. %s\r\n", i+1, choices[i])
+		fmt.Printf("%d. %s", i+1, choices[i])
+		if i == def {
+			ansi.Print(theme.Default, " (default)")
+		}
+		fmt.Print("\r\n")
 	}
 	fmt.Println("q. Quit")
 	ansi.Print(theme.Instruction, "Choice: ")
+	if def >= 0 && def < len(choices) {
+		ansi.Printf(theme.Default, "(%d) ", def+1)
+	}
 
 }
@@ -0,0 +1,126 @@
+// Package clui has command line ui functions
+package clui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bit101/go-ansi"
+	"github.com/bit101/tinfox/theme"
+	"golang.org/x/term"
+)
+
+// showSuggestions displays the suggested values for a prompt.
+func showSuggestions(suggestions []string) {
+	ansi.Printf(theme.Default, "  Suggestions: %s (tab to complete)\n", strings.Join(suggestions, ", "))
+}
+
+// readSuggested displays a prompt and reads a line in which tab completes from the suggestions.
+// Pressing tab again on a completed suggestion moves to the next one.
+// When stdin is not a terminal, or input has already been buffered, it reads a line as usual.
+func readSuggested(prompt, defaultValue string, suggestions []string) string {
+	fd := int(os.Stdin.Fd())
+	if !canReadRaw() {
+		return ReadStringDefault(prompt, defaultValue)
+	}
+	// The line editor draws the prompt itself, so that it knows where the line starts when redrawing.
+	var sb strings.Builder
+	ansi.Fprintf(&sb, theme.Instruction, "%s ", prompt)
+	if defaultValue != "" {
+		ansi.Fprintf(&sb, theme.Default, "(%s) ", defaultValue)
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Print(sb.String())
+		str, _ := reader.ReadString('\n')
+		return orDefault(strings.TrimSuffix(str, "\n"), defaultValue)
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{byteReader{}, os.Stdout}, "")
+	t.SetPrompt(sb.String() + string(t.Escape.Reset))
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		completed, ok := completeSuggestion(line, suggestions)
+		return completed, utf8.RuneCountInString(completed), ok
+	}
+	line, err := t.ReadLine()
+	term.Restore(fd, state)
+	if err != nil {
+		// Ctrl-C and Ctrl-D end the line editor in raw mode, so treat them as an interrupt.
+		fmt.Println()
+		os.Exit(1)
+	}
+	return orDefault(line, defaultValue)
+}
+
+// byteReader reads from reader one byte at a time, so that the line editor does not take
+// any input past the end of its line, which stays buffered in reader for the next prompt.
+type byteReader struct{}
+
+func (byteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	p[0] = b
+	return 1, nil
+}
+
+// orDefault returns the value, or def if the value is empty.
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// completeSuggestion returns the completion of line from the suggestions.
+// A line that is a whole suggestion moves on to the next suggestion.
+// A partial line is extended as far as all the matching suggestions agree,
+// or to the first match if that does not extend it.
+func completeSuggestion(line string, suggestions []string) (string, bool) {
+	for i, s := range suggestions {
+		if line != "" && strings.EqualFold(s, line) {
+			return suggestions[(i+1)%len(suggestions)], true
+		}
+	}
+	matches := []string{}
+	for _, s := range suggestions {
+		if strings.HasPrefix(strings.ToLower(s), strings.ToLower(line)) {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+	prefix := commonPrefix(matches)
+	if utf8.RuneCountInString(prefix) > utf8.RuneCountInString(line) {
+		return prefix, true
+	}
+	return matches[0], true
+}
+
+// commonPrefix returns the longest prefix, ignoring case, shared by all the strings, as written in the first.
+func commonPrefix(values []string) string {
+	prefix := []rune(values[0])
+	for _, value := range values[1:] {
+		runes := []rune(value)
+		n := 0
+		for n < len(prefix) && n < len(runes) && strings.EqualFold(string(prefix[n]), string(runes[n])) {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
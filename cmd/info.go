@@ -0,0 +1,22 @@
+// Package cmd has the tinfox commands
+package cmd
+
+import (
+	"github.com/bit101/tinfox/templates"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info <template>",
+	Short: "Show info about a template and its tokens",
+	Long:  `Show info about a template, given by name or directory, including its tokens and the rules their values must follow.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		parser := templates.NewTemplateParser()
+		parser.DisplayInfo(args[0])
+	},
+}
@@ -0,0 +1,67 @@
+// Package cmd has the tinfox commands
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bit101/tinfox/clui"
+	"github.com/bit101/tinfox/templates"
+	"github.com/bit101/tinfox/theme"
+	"github.com/spf13/cobra"
+)
+
+var setValues []string
+
+func init() {
+	newCmd.Flags().StringVarP(&valuesFile, "values", "v", "", "JSON or YAML answers file with token values")
+	newCmd.Flags().BoolVar(&strict, "strict", false, "stop without writing anything if a file uses an undefined token")
+	newCmd.Flags().StringArrayVarP(&setValues, "set", "s", nil, "set a token value as TOKEN=value (can be repeated)")
+	rootCmd.AddCommand(newCmd)
+}
+
+var newCmd = &cobra.Command{
+	Use:   "new [template] [path]",
+	Short: "Create a project from a template without the template menu",
+	Long: `Create a project from a template without the template menu.
+
+The template can be given by name or by directory. Token values can be
+supplied in a JSON or YAML answers file with --values, and with
+--set TOKEN=value, which overrides the file. The answers file can also name
+the template and path. Any other tokens are prompted for, or take their
+default values when stdin is not a terminal.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		parser := templates.NewTemplateParser()
+		parser.SetInteractive(clui.IsInteractive())
+		parser.SetStrict(strict)
+		if valuesFile != "" {
+			parser.UseAnswers(valuesFile)
+		}
+		for _, set := range setValues {
+			name, value, found := strings.Cut(set, "=")
+			if !found || name == "" {
+				theme.PrintErrorf("Invalid --set value %q. Use TOKEN=value.\n", set)
+				os.Exit(1)
+			}
+			parser.SetTokenValue(name, value)
+		}
+		templateName := parser.AnswersTemplate()
+		if len(args) > 0 {
+			templateName = args[0]
+		}
+		projectDir := parser.AnswersProjectDir()
+		if len(args) > 1 {
+			projectDir = args[1]
+		}
+		if templateName == "" || projectDir == "" {
+			theme.PrintErrorln("A template and a path are required, as arguments or in the --values file.")
+			os.Exit(1)
+		}
+		parser.UseTemplate(templateName)
+		parser.SetProjectDir(projectDir)
+		parser.DefineTokens()
+		parser.CreateProject()
+		parser.ShowSuccess()
+	},
+}
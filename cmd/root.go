@@ -9,12 +9,26 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	valuesFile string
+	strict     bool
+)
+
+func init() {
+	rootCmd.Flags().StringVarP(&valuesFile, "values", "v", "", "JSON or YAML answers file with token values")
+	rootCmd.Flags().BoolVar(&strict, "strict", false, "stop without writing anything if a file uses an undefined token")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "tinfox",
 	Short: "tinfox builds custom projects based on project templates.",
 	Long:  `tinfox builds custom projects based on project templates.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		parser := templates.NewTemplateParser()
+		parser.SetStrict(strict)
+		if valuesFile != "" {
+			parser.UseAnswers(valuesFile)
+		}
 		parser.LoadAndParse()
 	},
 }
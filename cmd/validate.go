@@ -0,0 +1,22 @@
+// Package cmd has the tinfox commands
+package cmd
+
+import (
+	"github.com/bit101/tinfox/templates"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <template>",
+	Short: "Check a template for problems",
+	Long:  `Check a template, given by name or directory, for problems such as references to undefined tokens.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		parser := templates.NewTemplateParser()
+		parser.ValidateTemplate(args[0])
+	},
+}
@@ -4,11 +4,10 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/bit101/tinfox/config"
 	"github.com/spf13/cobra"
 )
 
-const version = "v0.1.8"
-
 func init() {
 	rootCmd.AddCommand(versionCmd)
 }
@@ -18,6 +17,6 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version number of tinfox",
 	Long:  `Print the version number of tinfox`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("tinfox project creator %s\n", version)
+		fmt.Printf("tinfox project creator %s\n", config.Version)
 	},
 }
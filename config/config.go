@@ -12,6 +12,9 @@ import (
 	"github.com/bit101/tinfox/theme"
 )
 
+// Version is the current version of tinfox.
+const Version = "v0.1.8"
+
 // ActiveConfig is the current config in use.
 var ActiveConfig Config
 
@@ -24,6 +27,7 @@ type Config struct {
 	ErrorColor        string `json:"errorColor"`
 	DefaultValueColor string `json:"defaultValueColor"`
 	Verbose           bool   `json:"verbose"`
+	DisableCommands   bool   `json:"disableCommands"`
 	ConfigDir         string `json:"-"`
 }
 
@@ -48,6 +52,7 @@ func LoadConfig() {
 	var configuration Config
 	err = json.Unmarshal(configStr, &configuration)
 	checkError(err, "could not parse config.")
+	configuration.ConfigDir = filepath.Join(configDir, "tinfox")
 	if initializedConfig {
 		displayConfigSetupMessage(configDir)
 	}
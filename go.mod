@@ -5,11 +5,12 @@ go 1.22.1
 require (
 	github.com/bit101/go-ansi v1.5.4
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/term v0.13.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.13.0 // indirect
-	golang.org/x/term v0.13.0 // indirect
 )
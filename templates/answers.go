@@ -0,0 +1,48 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answers holds the values read from an answers file.
+type Answers struct {
+	Template   string         `json:"template" yaml:"template"`
+	ProjectDir string         `json:"projectDir" yaml:"projectDir"`
+	Tokens     map[string]any `json:"tokens" yaml:"tokens"`
+}
+
+// LoadAnswers reads and parses a JSON or YAML answers file.
+func LoadAnswers(path string) (*Answers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var answers Answers
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &answers)
+	default:
+		err = json.Unmarshal(data, &answers)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %q: %w", path, err)
+	}
+	return &answers, nil
+}
+
+// TokenValues returns the token values in the answers file as strings.
+// Arrays become newline separated lists.
+func (a *Answers) TokenValues() map[string]string {
+	values := map[string]string{}
+	for name, value := range a.Tokens {
+		values[name] = formatValue(value)
+	}
+	return values
+}
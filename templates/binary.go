@@ -0,0 +1,33 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// binaryExtensions lists file extensions that are always treated as binary.
+var binaryExtensions = []string{
+	".png", ".jpg", ".jpeg", ".gif", ".bmp", ".ico", ".webp", ".tif", ".tiff", ".psd",
+	".ttf", ".otf", ".woff", ".woff2", ".eot",
+	".zip", ".gz", ".tgz", ".bz2", ".xz", ".7z", ".rar", ".tar", ".jar",
+	".pdf", ".mp3", ".mp4", ".wav", ".ogg", ".flac", ".mov", ".avi", ".webm",
+	".exe", ".dll", ".so", ".dylib", ".a", ".o", ".class", ".pyc", ".wasm", ".bin",
+}
+
+// sniffLength is how much of a file is checked for NUL bytes.
+const sniffLength = 8000
+
+// isBinary reports whether a file should be copied byte for byte rather than have its tokens replaced.
+func isBinary(name string, data []byte) bool {
+	if slices.Contains(binaryExtensions, strings.ToLower(filepath.Ext(name))) {
+		return true
+	}
+	if bytes.IndexByte(data[:min(len(data), sniffLength)], 0) >= 0 {
+		return true
+	}
+	return !utf8.Valid(data)
+}
@@ -0,0 +1,269 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Block tags in file contents look like ${#if CONDITION}, ${else} and ${/if},
+// or ${#each LIST}, optionally ${#each LIST as NAME}, and ${/each}.
+// A line that contains nothing but a block tag is removed along with the tag.
+
+// node is a piece of parsed file content.
+type node interface{}
+
+// textNode is plain text that may contain placeholders.
+type textNode struct {
+	text string
+}
+
+// blockNode is a node that contains other nodes.
+type blockNode interface {
+	kind() string
+	startLine() int
+	addChild(n node)
+}
+
+// ifNode is a conditional block, with an optional else part.
+type ifNode struct {
+	condition string
+	line      int
+	then      []node
+	otherwise []node
+	inElse    bool
+}
+
+// eachNode is a block that is repeated for each item in a list token.
+type eachNode struct {
+	list string
+	item string
+	line int
+	body []node
+}
+
+func (n *ifNode) kind() string {
+	return "if"
+}
+
+func (n *ifNode) startLine() int {
+	return n.line
+}
+
+func (n *ifNode) addChild(child node) {
+	if n.inElse {
+		n.otherwise = append(n.otherwise, child)
+	} else {
+		n.then = append(n.then, child)
+	}
+}
+
+func (n *eachNode) kind() string {
+	return "each"
+}
+
+func (n *eachNode) startLine() int {
+	return n.line
+}
+
+func (n *eachNode) addChild(child node) {
+	n.body = append(n.body, child)
+}
+
+// blockTag is a block tag found in file contents.
+// An escaped tag has the text to write in place of the tag and its escape char as literal.
+type blockTag struct {
+	start, end int
+	content    string
+	line       int
+	literal    string
+}
+
+// renderContent evaluates the blocks in file contents and replaces the placeholders in the result.
+func renderContent(text, open, close string, tokens map[string]string) (string, error) {
+	nodes, err := parseBlocks(text, open, close)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := renderNodes(&sb, nodes, open, close, tokens); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// parseBlocks parses text into a tree of text and block nodes.
+func parseBlocks(text, open, close string) ([]node, error) {
+	root := []node{}
+	stack := []blockNode{}
+	add := func(n node) {
+		if len(stack) == 0 {
+			root = append(root, n)
+			return
+		}
+		stack[len(stack)-1].addChild(n)
+	}
+
+	pos := 0
+	for _, tag := range findBlockTags(text, open, close) {
+		if tag.start > pos {
+			add(textNode{text[pos:tag.start]})
+		}
+		pos = tag.end
+		if tag.literal != "" {
+			add(textNode{tag.literal})
+			continue
+		}
+		name, arg, _ := strings.Cut(tag.content, " ")
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "#if":
+			if arg == "" {
+				return nil, fmt.Errorf("line %d: %s#if%s needs a condition", tag.line, open, close)
+			}
+			block := &ifNode{condition: arg, line: tag.line}
+			add(block)
+			stack = append(stack, block)
+		case "#each":
+			list, item, found := strings.Cut(arg, " as ")
+			list, item = strings.TrimSpace(list), strings.TrimSpace(item)
+			if !found {
+				item = "ITEM"
+			}
+			if (!isTokenName(list) && !isDataName(list)) || !isTokenName(item) {
+				return nil, fmt.Errorf("line %d: %s%s%s should look like %s#each LIST%s or %s#each LIST as NAME%s",
+					tag.line, open, tag.content, close, open, close, open, close)
+			}
+			block := &eachNode{list: list, item: item, line: tag.line}
+			add(block)
+			stack = append(stack, block)
+		case "else":
+			var block *ifNode
+			if len(stack) > 0 {
+				block, _ = stack[len(stack)-1].(*ifNode)
+			}
+			if block == nil || block.inElse {
+				return nil, fmt.Errorf("line %d: unexpected %selse%s", tag.line, open, close)
+			}
+			block.inElse = true
+		case "/if", "/each":
+			if len(stack) == 0 || "/"+stack[len(stack)-1].kind() != name {
+				return nil, fmt.Errorf("line %d: unexpected %s%s%s", tag.line, open, name, close)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			return nil, fmt.Errorf("line %d: unknown block tag %s%s%s", tag.line, open, tag.content, close)
+		}
+	}
+	if pos < len(text) {
+		add(textNode{text[pos:]})
+	}
+	if len(stack) > 0 {
+		top := stack[len(stack)-1]
+		return nil, fmt.Errorf("line %d: %s#%s%s is never closed with %s/%s%s", top.startLine(), open, top.kind(), close, open, top.kind(), close)
+	}
+	return root, nil
+}
+
+// findBlockTags finds the block tags in text. When a tag is alone on its line,
+// the tag's range is widened to cover the whole line so that the line is removed.
+func findBlockTags(text, open, close string) []blockTag {
+	tags := []blockTag{}
+	pos := 0
+	for {
+		start := strings.Index(text[pos:], open)
+		if start < 0 {
+			return tags
+		}
+		start += pos
+		inner := start + len(open)
+		end := findClose(text[inner:], close)
+		if end < 0 {
+			pos = inner
+			continue
+		}
+		content := strings.TrimSpace(text[inner : inner+end])
+		pos = inner + end + len(close)
+		if !isBlockTag(content) {
+			continue
+		}
+		if isEscaped(text[:start]) {
+			tags = append(tags, blockTag{start: start - len(escapeChar), end: pos, literal: text[start:pos]})
+			continue
+		}
+		tag := blockTag{start: start, end: pos, content: content, line: strings.Count(text[:start], "\n") + 1}
+
+		lineStart := strings.LastIndex(text[:start], "\n") + 1
+		lineEnd := strings.Index(text[pos:], "\n")
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += pos + 1
+		}
+		prevEnd := 0
+		if len(tags) > 0 {
+			prevEnd = tags[len(tags)-1].end
+		}
+		if lineStart >= prevEnd && strings.TrimSpace(text[lineStart:start]) == "" && strings.TrimSpace(text[pos:lineEnd]) == "" {
+			tag.start = lineStart
+			tag.end = lineEnd
+			pos = lineEnd
+		}
+		tags = append(tags, tag)
+	}
+}
+
+// isBlockTag reports whether the content of a placeholder is a block tag rather than a token.
+// Anything else starting with # or /, such as ${#items[@]} in a shell script, is plain text.
+func isBlockTag(content string) bool {
+	word, _, _ := strings.Cut(content, " ")
+	switch word {
+	case "#if", "#each":
+		return true
+	case "else", "/if", "/each":
+		return word == content
+	}
+	return false
+}
+
+// renderNodes writes the rendered nodes to sb.
+func renderNodes(sb *strings.Builder, nodes []node, open, close string, tokens map[string]string) error {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case textNode:
+			text, err := replaceTokens(n.text, open, close, tokens)
+			if err != nil {
+				return err
+			}
+			sb.WriteString(text)
+		case *ifNode:
+			ok, err := evalCondition(n.condition, tokens)
+			if err != nil {
+				return fmt.Errorf("line %d: %s#if %s%s: %w", n.line, open, n.condition, close, err)
+			}
+			branch := n.then
+			if !ok {
+				branch = n.otherwise
+			}
+			if err := renderNodes(sb, branch, open, close, tokens); err != nil {
+				return err
+			}
+		case *eachNode:
+			list, ok := lookupToken(n.list, tokens)
+			if !ok {
+				return fmt.Errorf("line %d: %s#each %s%s: undefined token %s", n.line, open, n.list, close, n.list)
+			}
+			scope := maps.Clone(tokens)
+			for i, item := range listItems(list) {
+				scope[n.item] = item
+				scope["INDEX"] = strconv.Itoa(i)
+				if err := renderNodes(sb, n.body, open, close, scope); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package templates
+
+import "testing"
+
+func TestRenderContent(t *testing.T) {
+	tokens := map[string]string{
+		"NAME":     "app",
+		"USE_DB":   "true",
+		"MINIMAL":  "false",
+		"SERVICES": "api\nweb",
+		"EMPTY":    "",
+	}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain text", "hello", "hello"},
+		{"placeholder", "name=${NAME}", "name=app"},
+		{"if true", "${#if USE_DB}db${/if}", "db"},
+		{"if false", "${#if MINIMAL}min${/if}", ""},
+		{"else", "${#if MINIMAL}min${else}full${/if}", "full"},
+		{"negation", "${#if !MINIMAL}full${/if}", "full"},
+		{"nested", "${#if USE_DB}a${#if MINIMAL}b${else}c${/if}d${/if}", "acd"},
+		{"tag lines removed", "a\n${#if USE_DB}\nb\n${/if}\nc\n", "a\nb\nc\n"},
+		{"indented tag lines removed", "a\n  ${#if MINIMAL}\n  b\n  ${/if}\nc\n", "a\nc\n"},
+		{"each", "${#each SERVICES}${INDEX}:${ITEM} ${/each}", "0:api 1:web "},
+		{"each as", "${#each SERVICES as S}[${S}]${/each}", "[api][web]"},
+		{"each lines", "${#each SERVICES}\n- ${ITEM}\n${/each}\n", "- api\n- web\n"},
+		{"each empty list", "${#each EMPTY}x${/each}", ""},
+		{"escaped tag", `\${#if USE_DB}x\${/if}`, "${#if USE_DB}x${/if}"},
+		{"escaped token", `\${NAME}`, "${NAME}"},
+		{"escaped shell variable", `echo "\${HOME}"`, `echo "\${HOME}"`},
+		{"escaped shell array", `\${#items[@]}`, `\${#items[@]}`},
+		{"shell array length", "n=${#items[@]} len=${#NAME}", "n=${#items[@]} len=${#NAME}"},
+		{"shell suffix removal", "${path/#prefix} ${x/y/z}", "${path/#prefix} ${x/y/z}"},
+		{"else with argument", "${else x}", "${else x}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderContent(tt.text, "${", "}", tokens)
+			if err != nil {
+				t.Fatalf("renderContent(%q) error: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("renderContent(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderContentErrors(t *testing.T) {
+	tokens := map[string]string{"USE_DB": "true"}
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"unclosed if", "${#if USE_DB}x"},
+		{"unopened end", "x${/if}"},
+		{"mismatched end", "${#if USE_DB}x${/each}"},
+		{"if without condition", "${#if}x${/if}"},
+		{"double else", "${#if USE_DB}a${else}b${else}c${/if}"},
+		{"else outside if", "a${else}b"},
+		{"undefined list", "${#each NOPE}x${/each}"},
+		{"bad condition", "${#if USE_DB &&}x${/if}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := renderContent(tt.text, "${", "}", tokens); err == nil {
+				t.Errorf("renderContent(%q) gave no error", tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bit101/tinfox/config"
+)
+
+// builtinTokens are the tokens that are defined for every project.
+var builtinTokens = []string{
+	"PROJECT_PATH", "PROJECT_DIR",
+	"TEMPLATE_NAME", "TEMPLATE_DIR", "TINFOX_VERSION",
+	"YEAR", "DATE", "DATETIME", "UUID",
+	"OS_USER", "HOSTNAME",
+}
+
+// dynamicPrefixes are the built-in tokens that take an argument after a colon, as in ${env:HOME}.
+var dynamicPrefixes = []string{"env", "RANDOM_HEX"}
+
+// defineBuiltins adds the values of the built-in tokens to the template's token values.
+func (t *Template) defineBuiltins() {
+	now := time.Now()
+	t.TokenValues["PROJECT_PATH"] = t.ProjectDir
+	t.TokenValues["PROJECT_DIR"] = filepath.Base(t.ProjectDir)
+	t.TokenValues["TEMPLATE_NAME"] = t.Name
+	t.TokenValues["TEMPLATE_DIR"] = t.TemplateSourceDir
+	t.TokenValues["TINFOX_VERSION"] = config.Version
+	t.TokenValues["YEAR"] = now.Format("2006")
+	t.TokenValues["DATE"] = now.Format(time.DateOnly)
+	t.TokenValues["DATETIME"] = now.Format(time.RFC3339)
+	t.TokenValues["UUID"] = newUUID()
+	t.TokenValues["OS_USER"] = osUser()
+	t.TokenValues["HOSTNAME"], _ = os.Hostname()
+}
+
+// dynamicToken returns the value of a built-in token that takes an argument.
+// ${env:NAME} is the value of the NAME environment variable, and is not found if it is not set.
+// ${RANDOM_HEX:n} is n random hex digits, different each time it is used.
+func dynamicToken(name string) (string, bool) {
+	prefix, arg, _ := strings.Cut(name, ":")
+	switch prefix {
+	case "env":
+		return os.LookupEnv(arg)
+	case "RANDOM_HEX":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return "", false
+		}
+		return randomHex(n), true
+	}
+	return "", false
+}
+
+// isDynamicName reports whether name looks like a built-in token that takes an argument.
+func isDynamicName(name string) bool {
+	prefix, arg, found := strings.Cut(name, ":")
+	if !found || arg == "" || strings.ContainsAny(arg, " \t") {
+		return false
+	}
+	for _, p := range dynamicPrefixes {
+		if prefix == p {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupToken returns the value of a token, including the built-in tokens that take an argument
+// and references to data values.
+func lookupToken(name string, tokens map[string]string) (string, bool) {
+	if value, ok := tokens[name]; ok {
+		return value, true
+	}
+	if isDataName(name) {
+		return lookupData(name, tokens)
+	}
+	return dynamicToken(name)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, (n+1)/2)
+	rand.Read(b)
+	return hex.EncodeToString(b)[:n]
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// osUser returns the name of the current user.
+func osUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
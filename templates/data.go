@@ -0,0 +1,167 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dataPrefix starts the names of data values, as in ${data.licenses[LICENSE].header}.
+const dataPrefix = "data"
+
+// defineData loads the template's data files and adds their values to its token values.
+func (t *Template) defineData() error {
+	values, err := t.loadData()
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		t.TokenValues[name] = value
+	}
+	return nil
+}
+
+// loadData reads the JSON and YAML files in the template's data dir, if it has one.
+// Each value is stored under a name made of the file name and the keys
+// and indexes that lead to it, as in data.licenses.MIT.header.
+// Lists of plain values are also stored whole, as newline separated lists.
+func (t *Template) loadData() (map[string]string, error) {
+	values := map[string]string{}
+	if t.DataDir == "" {
+		return values, nil
+	}
+	dataDir := filepath.Join(t.TemplateSourceDir, cleanIncludePath(t.DataDir))
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read dataDir: %w", err)
+	}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dataDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if ext == ".json" {
+			err = json.Unmarshal(data, &value)
+		} else {
+			err = yaml.Unmarshal(data, &value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse data file %s: %w", entry.Name(), err)
+		}
+		flattenData(dataPrefix+"."+strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), value, values)
+	}
+	return values, nil
+}
+
+// flattenData stores the value, and any values inside it, under names starting with prefix.
+func flattenData(prefix string, value any, values map[string]string) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			flattenData(prefix+"."+key, item, values)
+		}
+	case []any:
+		plain := true
+		for i, item := range value {
+			switch item.(type) {
+			case map[string]any, []any:
+				plain = false
+			}
+			flattenData(prefix+"."+strconv.Itoa(i), item, values)
+		}
+		if plain {
+			values[prefix] = formatValue(value)
+		}
+	default:
+		values[prefix] = formatValue(value)
+	}
+}
+
+// isDataName reports whether name looks like a reference to a data value.
+func isDataName(name string) bool {
+	_, ok := dataPath(name)
+	return ok
+}
+
+// dataPath splits a data reference such as data.licenses[LICENSE].header into its parts.
+// Parts in brackets keep their brackets, and can be token names, "quoted strings" or numbers.
+func dataPath(name string) ([]string, bool) {
+	rest, found := strings.CutPrefix(name, dataPrefix)
+	if !found || rest == "" {
+		return nil, false
+	}
+	parts := []string{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" || strings.ContainsAny(key, " \t]\"") {
+				return nil, false
+			}
+			parts = append(parts, key)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, false
+			}
+			key := strings.TrimSpace(rest[1:end])
+			if _, err := strconv.Unquote(key); err != nil && !isTokenName(key) && !isIndex(key) {
+				return nil, false
+			}
+			parts = append(parts, "["+key+"]")
+			rest = rest[end+1:]
+		default:
+			return nil, false
+		}
+	}
+	return parts, true
+}
+
+// lookupData returns the data value for a data reference. Token names in brackets are replaced by their values.
+func lookupData(name string, tokens map[string]string) (string, bool) {
+	parts, ok := dataPath(name)
+	if !ok {
+		return "", false
+	}
+	keys := []string{dataPrefix}
+	for _, part := range parts {
+		key, found := strings.CutPrefix(part, "[")
+		if !found {
+			keys = append(keys, part)
+			continue
+		}
+		key = strings.TrimSuffix(key, "]")
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		} else if !isIndex(key) {
+			if key, ok = tokens[key]; !ok {
+				return "", false
+			}
+		}
+		keys = append(keys, key)
+	}
+	value, ok := tokens[strings.Join(keys, ".")]
+	return value, ok
+}
+
+// isIndex reports whether key is a list index.
+func isIndex(key string) bool {
+	n, err := strconv.Atoi(key)
+	return err == nil && n >= 0
+}
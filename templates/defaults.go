@@ -0,0 +1,92 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bit101/tinfox/config"
+	"github.com/bit101/tinfox/theme"
+)
+
+// commandTimeout is how long a token's default command may run before it is stopped.
+const commandTimeout = 5 * time.Second
+
+// sourcedDefault returns the token's default as given by its default command or default file.
+// If neither gives a value that passes Check, the token's static default is returned.
+func sourcedDefault(token Token) string {
+	if token.DefaultCommand != "" && !config.ActiveConfig.DisableCommands {
+		value, err := runDefaultCommand(token.DefaultCommand)
+		if err == nil {
+			err = token.Check(value)
+		}
+		if err == nil {
+			return value
+		}
+		if config.ActiveConfig.Verbose {
+			theme.PrintDefaultf("  Default command for %s failed: %s\n", token.Name, err)
+		}
+	}
+	if token.DefaultFile != "" {
+		value, err := readDefaultFile(token.DefaultFile)
+		if err == nil {
+			err = token.Check(value)
+		}
+		if err == nil {
+			return value
+		}
+		if config.ActiveConfig.Verbose {
+			theme.PrintDefaultf("  Default file for %s failed: %s\n", token.Name, err)
+		}
+	}
+	return token.Default
+}
+
+// runDefaultCommand runs the command in the shell and returns its trimmed output.
+func runDefaultCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// Stop waiting for output held open by any child processes left behind.
+	cmd.WaitDelay = time.Second
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("timed out after %s", commandTimeout)
+	}
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(output))
+	if value == "" {
+		return "", errors.New("no output")
+	}
+	return value, nil
+}
+
+// readDefaultFile reads the file, after expanding its path, and returns its trimmed contents.
+func readDefaultFile(path string) (string, error) {
+	path, err := expandPath(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", errors.New("file is empty")
+	}
+	return value, nil
+}
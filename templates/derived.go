@@ -0,0 +1,80 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// resolveDefault returns the token's default with any placeholders replaced by the values defined so far.
+func (t *Template) resolveDefault(token Token) (string, error) {
+	open, close := t.contentDelimiters()
+	return expandPlaceholders(token.Default, open, close, func(ref string) (string, bool, error) {
+		value, ok := lookupToken(ref, t.TokenValues)
+		if !ok {
+			return "", false, fmt.Errorf("default for %s references %s, which is not defined before it", token.Name, ref)
+		}
+		return value, true, nil
+	})
+}
+
+// defineDerived evaluates the template's derived tokens and adds them to its token values.
+// Derived tokens can reference tokens, built-in tokens and other derived tokens.
+func (t *Template) defineDerived() error {
+	names := []string{}
+	for name := range t.Derived {
+		if t.findToken(name) != nil {
+			return fmt.Errorf("derived token %s has the same name as a token", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	open, close := t.contentDelimiters()
+	resolving := []string{}
+	var resolve func(name string) (string, error)
+	resolve = func(name string) (string, error) {
+		if value, ok := t.TokenValues[name]; ok {
+			return value, nil
+		}
+		if slices.Contains(resolving, name) {
+			cycle := append(resolving[slices.Index(resolving, name):], name)
+			return "", fmt.Errorf("derived tokens form a cycle: %s", strings.Join(cycle, " -> "))
+		}
+		resolving = append(resolving, name)
+		value, err := expandPlaceholders(t.Derived[name], open, close, func(ref string) (string, bool, error) {
+			if value, ok := dynamicToken(ref); ok {
+				return value, true, nil
+			}
+			if isDataName(ref) {
+				value, ok := lookupData(ref, t.TokenValues)
+				if !ok {
+					return "", false, fmt.Errorf("derived token %s references undefined data %s", name, ref)
+				}
+				return value, true, nil
+			}
+			if _, ok := t.TokenValues[ref]; !ok {
+				if _, ok := t.Derived[ref]; !ok {
+					return "", false, fmt.Errorf("derived token %s references undefined token %s", name, ref)
+				}
+			}
+			value, err := resolve(ref)
+			return value, true, err
+		})
+		if err != nil {
+			return "", err
+		}
+		resolving = resolving[:len(resolving)-1]
+		t.TokenValues[name] = value
+		return value, nil
+	}
+
+	for _, name := range names {
+		if _, err := resolve(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// expr is a parsed condition expression, as used for conditional file inclusion.
+//
+// Expressions are made of token names, "quoted strings", numbers, true and false,
+// combined with ==, !=, <, <=, >, >=, !, && and || and grouped with parentheses.
+// The functions in exprFuncs can be called as in endsWith(MODULE_PATH, PROJECT_DIR).
+// A value is false if it is empty, "false" or "0", and true otherwise.
+type expr interface {
+	eval(vars map[string]string) (string, error)
+	refs() []string
+}
+
+type literalExpr struct {
+	value string
+}
+
+type refExpr struct {
+	name string
+}
+
+type notExpr struct {
+	operand expr
+}
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+// exprFunc is a function that can be called in an expression.
+type exprFunc struct {
+	argCount int
+	call     func(args []string) (string, error)
+}
+
+// exprFuncs are the functions that can be called in expressions.
+var exprFuncs = map[string]exprFunc{
+	"startsWith": {2, func(args []string) (string, error) {
+		return boolString(strings.HasPrefix(args[0], args[1])), nil
+	}},
+	"endsWith": {2, func(args []string) (string, error) {
+		return boolString(strings.HasSuffix(args[0], args[1])), nil
+	}},
+	"contains": {2, func(args []string) (string, error) {
+		return boolString(strings.Contains(args[0], args[1])), nil
+	}},
+	"matches": {2, func(args []string) (string, error) {
+		re, err := regexp.Compile(args[1])
+		if err != nil {
+			return "", fmt.Errorf("bad pattern %q: %w", args[1], err)
+		}
+		return boolString(re.MatchString(args[0])), nil
+	}},
+	"len": {1, func(args []string) (string, error) {
+		return strconv.Itoa(utf8.RuneCountInString(args[0])), nil
+	}},
+}
+
+// evalCondition parses and evaluates a condition expression against the given token values.
+func evalCondition(condition string, vars map[string]string) (bool, error) {
+	e, err := parseExpr(condition)
+	if err != nil {
+		return false, err
+	}
+	value, err := e.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	return isTruthy(value), nil
+}
+
+// isTruthy reports whether a value counts as true in a condition.
+func isTruthy(value string) bool {
+	return value != "" && value != "false" && value != "0"
+}
+
+func boolString(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+func (e literalExpr) eval(vars map[string]string) (string, error) {
+	return e.value, nil
+}
+
+func (e literalExpr) refs() []string {
+	return nil
+}
+
+func (e refExpr) eval(vars map[string]string) (string, error) {
+	value, ok := vars[e.name]
+	if !ok {
+		return "", fmt.Errorf("undefined token %s", e.name)
+	}
+	return value, nil
+}
+
+func (e refExpr) refs() []string {
+	return []string{e.name}
+}
+
+func (e notExpr) eval(vars map[string]string) (string, error) {
+	value, err := e.operand.eval(vars)
+	if err != nil {
+		return "", err
+	}
+	return boolString(!isTruthy(value)), nil
+}
+
+func (e notExpr) refs() []string {
+	return e.operand.refs()
+}
+
+func (e binaryExpr) eval(vars map[string]string) (string, error) {
+	left, err := e.left.eval(vars)
+	if err != nil {
+		return "", err
+	}
+	// && and || only evaluate the right side when needed.
+	if e.op == "&&" && !isTruthy(left) || e.op == "||" && isTruthy(left) {
+		return boolString(isTruthy(left)), nil
+	}
+	right, err := e.right.eval(vars)
+	if err != nil {
+		return "", err
+	}
+	switch e.op {
+	case "&&", "||":
+		return boolString(isTruthy(right)), nil
+	case "==":
+		return boolString(compareValues(left, right) == 0), nil
+	case "!=":
+		return boolString(compareValues(left, right) != 0), nil
+	case "<":
+		return boolString(compareValues(left, right) < 0), nil
+	case "<=":
+		return boolString(compareValues(left, right) <= 0), nil
+	case ">":
+		return boolString(compareValues(left, right) > 0), nil
+	case ">=":
+		return boolString(compareValues(left, right) >= 0), nil
+	}
+	return "", fmt.Errorf("unknown operator %s", e.op)
+}
+
+func (e binaryExpr) refs() []string {
+	return append(e.left.refs(), e.right.refs()...)
+}
+
+func (e callExpr) eval(vars map[string]string) (string, error) {
+	args := []string{}
+	for _, arg := range e.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, value)
+	}
+	return exprFuncs[e.name].call(args)
+}
+
+func (e callExpr) refs() []string {
+	refs := []string{}
+	for _, arg := range e.args {
+		refs = append(refs, arg.refs()...)
+	}
+	return refs
+}
+
+// compareValues compares two values as numbers if they both are numbers, and as strings otherwise.
+func compareValues(a, b string) int {
+	af, errA := strconv.ParseFloat(a, 64)
+	bf, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+//////////////////////////////
+// Parser
+//////////////////////////////
+
+// exprParser is a recursive descent parser for condition expressions.
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseExpr parses a condition expression.
+func parseExpr(text string) (expr, error) {
+	tokens, err := lexExpr(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in %q", p.tokens[p.pos], text)
+	}
+	return e, nil
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{"||", left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{"&&", left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.peek() == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return binaryExpr{op, left, right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "(":
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return e, nil
+	case tok[0] == '"':
+		value, err := strconv.Unquote(tok)
+		if err != nil {
+			return nil, fmt.Errorf("bad string %s", tok)
+		}
+		return literalExpr{value}, nil
+	case tok == "true" || tok == "false":
+		return literalExpr{tok}, nil
+	case unicode.IsDigit(rune(tok[0])) || tok[0] == '-':
+		if _, err := strconv.ParseFloat(tok, 64); err != nil {
+			return nil, fmt.Errorf("bad number %s", tok)
+		}
+		return literalExpr{tok}, nil
+	case isTokenName(tok) && p.peek() == "(":
+		return p.parseCall(tok)
+	case isTokenName(tok):
+		return refExpr{tok}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+func (p *exprParser) parseCall(name string) (expr, error) {
+	f, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	p.next()
+	args := []expr{}
+	for p.peek() != ")" {
+		if len(args) > 0 && p.next() != "," {
+			return nil, fmt.Errorf("expected , or ) in call to %s", name)
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+	if len(args) != f.argCount {
+		return nil, fmt.Errorf("%s takes %d arguments", name, f.argCount)
+	}
+	return callExpr{name, args}, nil
+}
+
+// lexExpr splits an expression into operators, parentheses, strings, numbers and names.
+func lexExpr(text string) ([]string, error) {
+	tokens := []string{}
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(text[i:], "&&") || strings.HasPrefix(text[i:], "||") ||
+			strings.HasPrefix(text[i:], "==") || strings.HasPrefix(text[i:], "!=") ||
+			strings.HasPrefix(text[i:], "<=") || strings.HasPrefix(text[i:], ">="):
+			tokens = append(tokens, text[i:i+2])
+			i += 2
+		case c == '!' || c == '<' || c == '>':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) {
+				return nil, fmt.Errorf("unterminated string in %q", text)
+			}
+			tokens = append(tokens, text[i:end+1])
+			i = end + 1
+		default:
+			end := i
+			for end < len(text) && isWordChar(text[end]) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("unexpected %q in %q", string(c), text)
+			}
+			tokens = append(tokens, text[i:end])
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
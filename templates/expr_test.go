@@ -0,0 +1,113 @@
+package templates
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	vars := map[string]string{
+		"USE_DB":   "true",
+		"MINIMAL":  "false",
+		"DATABASE": "postgres",
+		"REPLICAS": "3",
+		"PORT":     "8080",
+		"EMPTY":    "",
+		"ZERO":     "0",
+		"MODULE":   "github.com/acme/app",
+		"DIR":      "app",
+	}
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{"USE_DB", true},
+		{"MINIMAL", false},
+		{"EMPTY", false},
+		{"ZERO", false},
+		{"!MINIMAL", true},
+		{"!!USE_DB", true},
+		{"USE_DB && !MINIMAL", true},
+		{"USE_DB && MINIMAL", false},
+		{"MINIMAL || USE_DB", true},
+		{"MINIMAL || EMPTY", false},
+		{`DATABASE == "postgres"`, true},
+		{`DATABASE != "none"`, true},
+		{"REPLICAS > 1", true},
+		{"REPLICAS >= 3", true},
+		{"REPLICAS < 3", false},
+		{"REPLICAS <= 2", false},
+		{"PORT > 900", true},
+		{`"b" > "a"`, true},
+		{"true", true},
+		{"false", false},
+		{"1 == 1.0", true},
+		{"MINIMAL || USE_DB && REPLICAS > 5", false},
+		{"(MINIMAL || USE_DB) && REPLICAS > 1", true},
+		{"!(USE_DB && MINIMAL)", true},
+		{"endsWith(MODULE, DIR)", true},
+		{`startsWith(MODULE, "github.com/")`, true},
+		{`contains(MODULE, "acme")`, true},
+		{`matches(DIR, "^[a-z]+$")`, true},
+		{"len(DIR) == 3", true},
+		{"!endsWith(MODULE, DATABASE)", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			got, err := evalCondition(tt.cond, vars)
+			if err != nil {
+				t.Fatalf("evalCondition(%q) error: %v", tt.cond, err)
+			}
+			if got != tt.want {
+				t.Errorf("evalCondition(%q) = %v, want %v", tt.cond, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalConditionErrors(t *testing.T) {
+	vars := map[string]string{"USE_DB": "true"}
+	tests := []string{
+		"",
+		"USE_DB &&",
+		"(USE_DB",
+		"USE_DB)",
+		"UNDEFINED",
+		`"unclosed`,
+		"USE_DB = true",
+		"nope(USE_DB)",
+		"len(USE_DB, USE_DB)",
+		"endsWith(USE_DB)",
+		`matches(USE_DB, "[")`,
+	}
+	for _, cond := range tests {
+		t.Run(cond, func(t *testing.T) {
+			if _, err := evalCondition(cond, vars); err == nil {
+				t.Errorf("evalCondition(%q) gave no error", cond)
+			}
+		})
+	}
+}
+
+func TestExprRefs(t *testing.T) {
+	tests := []struct {
+		cond string
+		want []string
+	}{
+		{"A", []string{"A"}},
+		{`A && B == "x" || !C`, []string{"A", "B", "C"}},
+		{"endsWith(A, B)", []string{"A", "B"}},
+		{"true && 1 < 2", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			e, err := parseExpr(tt.cond)
+			if err != nil {
+				t.Fatalf("parseExpr(%q) error: %v", tt.cond, err)
+			}
+			if got := e.refs(); !slices.Equal(got, tt.want) {
+				t.Errorf("refs(%q) = %v, want %v", tt.cond, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// filterFunc transforms a token value. arg is the text after the colon in name:arg, if any.
+type filterFunc func(value, arg string) (string, error)
+
+// filters maps filter names to the functions that apply them.
+var filters = map[string]filterFunc{}
+
+func init() {
+	filters["upper"] = simpleFilter(strings.ToUpper)
+	filters["lower"] = simpleFilter(strings.ToLower)
+	filters["trim"] = simpleFilter(strings.TrimSpace)
+	filters["snake"] = simpleFilter(toSnake)
+	filters["kebab"] = simpleFilter(toKebab)
+	filters["camel"] = simpleFilter(toCamel)
+	filters["pascal"] = simpleFilter(toPascal)
+	filters["title"] = simpleFilter(toTitle)
+	filters["slug"] = simpleFilter(toSlug)
+	filters["join"] = join
+	filters["date"] = formatDate
+}
+
+// applyFilter applies the named filter to the value.
+func applyFilter(name, arg, value string) (string, error) {
+	filter, ok := filters[name]
+	if !ok {
+		return "", fmt.Errorf("unknown filter %q", name)
+	}
+	return filter(value, arg)
+}
+
+func simpleFilter(f func(string) string) filterFunc {
+	return func(value, arg string) (string, error) {
+		return f(value), nil
+	}
+}
+
+// join joins the items of a list value with the separator given as its arg, or ", " by default.
+func join(value, arg string) (string, error) {
+	if arg == "" {
+		arg = ", "
+	}
+	return strings.Join(listItems(value), arg), nil
+}
+
+// dateLayouts are the layouts that the date filter can read values in.
+var dateLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly, "2006"}
+
+// formatDate reformats a date value, such as DATE or DATETIME, with the Go time layout given as its arg.
+func formatDate(value, arg string) (string, error) {
+	if arg == "" {
+		return "", fmt.Errorf("the date filter needs a layout, as in date:\"Jan 2, 2006\"")
+	}
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format(arg), nil
+		}
+	}
+	return "", fmt.Errorf("the date filter cannot read %q as a date", value)
+}
+
+// splitWords splits a value into words on separators and case changes.
+// "myApp name", "my_app_name", "MyAppName" and "HTTPServer" all split as expected.
+func splitWords(value string) []string {
+	words := []string{}
+	runes := []rune(value)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		lowerToUpper := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		acronymEnd := unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) == 0 {
+		return ""
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func toSnake(value string) string {
+	return strings.ToLower(strings.Join(splitWords(value), "_"))
+}
+
+func toKebab(value string) string {
+	return strings.ToLower(strings.Join(splitWords(value), "-"))
+}
+
+func toPascal(value string) string {
+	words := splitWords(value)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, "")
+}
+
+func toCamel(value string) string {
+	words := splitWords(value)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+func toTitle(value string) string {
+	words := splitWords(value)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// toSlug lowercases the value and replaces each run of other characters with a single dash.
+func toSlug(value string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteRune('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return sb.String()
+}
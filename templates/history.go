@@ -0,0 +1,70 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bit101/tinfox/config"
+)
+
+// historyFileName is the file in the config dir that remembers the token values used in earlier projects.
+const historyFileName = "history.json"
+
+// historySize is how many values are remembered for each token name.
+const historySize = 10
+
+// loadHistory reads the remembered token values, most recent first. A missing or bad file gives no history.
+func loadHistory() map[string][]string {
+	history := map[string][]string{}
+	data, err := os.ReadFile(filepath.Join(config.ActiveConfig.ConfigDir, historyFileName))
+	if err != nil {
+		return history
+	}
+	json.Unmarshal(data, &history)
+	return history
+}
+
+// saveHistory adds the values of the template's free text tokens to the history.
+// Secret tokens, and tokens with choices, bool or list values, are never saved.
+func (t *Template) saveHistory() error {
+	history := loadHistory()
+	for _, token := range t.Tokens {
+		value := t.TokenValues[token.Name]
+		if !token.remembers() || value == "" {
+			continue
+		}
+		values := slices.DeleteFunc(history[token.Name], func(v string) bool { return v == value })
+		values = append([]string{value}, values...)
+		if len(values) > historySize {
+			values = values[:historySize]
+		}
+		history[token.Name] = values
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.ActiveConfig.ConfigDir, historyFileName), data, 0644)
+}
+
+// remembers reports whether the token's values are saved in the history.
+func (tk Token) remembers() bool {
+	return !tk.Secret && len(tk.Choices) == 0 && tk.Type != TypeBool && tk.Type != TypeList
+}
+
+// allSuggestions returns the token's suggestions followed by any values remembered for it that pass Check.
+func (tk Token) allSuggestions() []string {
+	suggestions := slices.Clone(tk.Suggestions)
+	if !tk.remembers() {
+		return suggestions
+	}
+	for _, value := range loadHistory()[tk.Name] {
+		if !slices.Contains(suggestions, value) && tk.Check(value) == nil {
+			suggestions = append(suggestions, value)
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,146 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFileName is the optional file in a template root that lists more ignore patterns.
+const ignoreFileName = ".tinfoxignore"
+
+// vcsIgnore lists version control metadata that is never copied to a project.
+// These are not dir-only patterns, as submodules and worktrees have a .git file.
+var vcsIgnore = []string{".git", ".hg", ".svn"}
+
+// ignoreRule is a single gitignore style pattern.
+type ignoreRule struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList is a list of gitignore style patterns. When several patterns match a path, the last one wins.
+type ignoreList []ignoreRule
+
+// loadIgnoreList compiles the template's ignore patterns: the version control dirs,
+// the data dir, the ignore array in template.json, then the lines of any .tinfoxignore file.
+func (t *Template) loadIgnoreList() (ignoreList, error) {
+	patterns := append([]string{}, vcsIgnore...)
+	if t.DataDir != "" {
+		patterns = append(patterns, "/"+cleanIncludePath(t.DataDir)+"/")
+	}
+	patterns = append(patterns, t.Ignore...)
+	data, err := os.ReadFile(filepath.Join(t.TemplateSourceDir, ignoreFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		patterns = append(patterns, strings.Split(string(data), "\n")...)
+	}
+	return parseIgnore(patterns)
+}
+
+// parseIgnore compiles gitignore style patterns. Blank lines and lines starting with # are skipped.
+//
+// A pattern starting with ! re-includes paths matched by earlier patterns. A pattern ending in /
+// only matches directories. A pattern containing a / is matched against the whole path from the
+// template root, and any other pattern is matched against names at every depth. * and ? match
+// within a path segment, and ** matches any number of segments.
+func parseIgnore(patterns []string) (ignoreList, error) {
+	list := ignoreList{}
+	for _, pattern := range patterns {
+		rule := ignoreRule{pattern: pattern}
+		p := strings.TrimSpace(pattern)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		if strings.HasPrefix(p, "!") {
+			rule.negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			rule.dirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if !strings.Contains(p, "/") {
+			p = "**/" + p
+		}
+		p = strings.TrimPrefix(p, "/")
+		re, err := regexp.Compile("^" + globToRegexp(p) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("bad ignore pattern %q: %w", pattern, err)
+		}
+		rule.re = re
+		list = append(list, rule)
+	}
+	return list, nil
+}
+
+// globToRegexp converts a glob with *, ?, ** and [...] classes to a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// matches reports whether a path, relative to the template root and using / separators, is ignored.
+func (l ignoreList) matches(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range l {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchesFile reports whether a file is matched, either itself or through one of the dirs it is in.
+func (l ignoreList) matchesFile(relPath string) bool {
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if l.matches(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return l.matches(relPath, false)
+}
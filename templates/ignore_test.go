@@ -0,0 +1,76 @@
+package templates
+
+import "testing"
+
+func TestIgnoreMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"name at top", []string{"notes.txt"}, "notes.txt", false, true},
+		{"name at depth", []string{"notes.txt"}, "a/b/notes.txt", false, true},
+		{"star", []string{"*.swp"}, "src/main.go.swp", false, true},
+		{"star stays in segment", []string{"src/*.go"}, "src/a/main.go", false, false},
+		{"double star", []string{"**/*.swp"}, "a/b/c.swp", false, true},
+		{"double star in middle", []string{"docs/**/draft.md"}, "docs/a/b/draft.md", false, true},
+		{"double star matches no dirs", []string{"docs/**/draft.md"}, "docs/draft.md", false, true},
+		{"anchored", []string{"/build"}, "build", true, true},
+		{"anchored not at depth", []string{"/build"}, "src/build", true, false},
+		{"question mark", []string{"file?.txt"}, "file1.txt", false, true},
+		{"class", []string{"file[0-9].txt"}, "file7.txt", false, true},
+		{"negated class", []string{"file[!0-9].txt"}, "file7.txt", false, false},
+		{"dir only matches dir", []string{"node_modules/"}, "node_modules", true, true},
+		{"dir only skips file", []string{"node_modules/"}, "node_modules", false, false},
+		{"negation", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"last match wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"comment", []string{"# notes.txt"}, "notes.txt", false, false},
+		{"blank", []string{"", "  "}, "notes.txt", false, false},
+		{"escaped star", []string{`a\*.txt`}, "a*.txt", false, true},
+		{"escaped star literal", []string{`a\*.txt`}, "ab.txt", false, false},
+		{"dot is literal", []string{"a.txt"}, "abtxt", false, false},
+		{"vcs dir", vcsIgnore, ".git", true, true},
+		{"vcs file", vcsIgnore, ".git", false, true},
+		{"vcs nested", vcsIgnore, "sub/.hg", true, true},
+		{"gitignore kept", vcsIgnore, ".gitignore", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := parseIgnore(tt.patterns)
+			if err != nil {
+				t.Fatalf("parseIgnore(%q) error: %v", tt.patterns, err)
+			}
+			if got := list.matches(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("matches(%q, %v) with %q = %v, want %v", tt.path, tt.isDir, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatchesFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"inside ignored dir", []string{"node_modules/"}, "node_modules/x/index.js", true},
+		{"inside anchored dir", []string{"docs/drafts/"}, "docs/drafts/a.md", true},
+		{"dir pattern not file", []string{"docs/drafts/"}, "docs/drafts.md", false},
+		{"inside vcs dir", vcsIgnore, ".git/config", true},
+		{"not ignored", []string{"*.log"}, "src/main.go", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := parseIgnore(tt.patterns)
+			if err != nil {
+				t.Fatalf("parseIgnore(%q) error: %v", tt.patterns, err)
+			}
+			if got := list.matchesFile(tt.path); got != tt.want {
+				t.Errorf("matchesFile(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
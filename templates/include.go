@@ -0,0 +1,30 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// isIncluded evaluates the include condition, if any, for a file or dir in the template.
+// relPath is relative to the template root and uses / separators.
+func (t *Template) isIncluded(relPath string) (bool, error) {
+	for path, condition := range t.Include {
+		if cleanIncludePath(path) != relPath {
+			continue
+		}
+		included, err := evalCondition(condition, t.TokenValues)
+		if err != nil {
+			return false, fmt.Errorf("include condition %q: %w", condition, err)
+		}
+		return included, nil
+	}
+	return true, nil
+}
+
+// cleanIncludePath converts an include path such as "./ci/" to the form "ci".
+func cleanIncludePath(path string) string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	return strings.TrimSuffix(path, "/")
+}
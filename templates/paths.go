@@ -0,0 +1,152 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Path kinds, bases and forms for isPath tokens.
+const (
+	PathFile     = "file"
+	PathDir      = "dir"
+	PathCwd      = "cwd"
+	PathProject  = "project"
+	PathAbsolute = "absolute"
+)
+
+// checkPath checks a path token's value, after expanding it, against the token's path rules.
+func (tk Token) checkPath(value string) error {
+	if value == "" {
+		return errors.New("path cannot be empty")
+	}
+	path, err := expandPath(value)
+	if err != nil {
+		return err
+	}
+	if c, found := findInvalidPathChar(path); found {
+		return fmt.Errorf("path cannot contain %q", c)
+	}
+	if !tk.checksFiles() {
+		return nil
+	}
+
+	info, err := os.Stat(tk.absPath(path))
+	switch {
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return err
+	case err != nil && tk.MustExist:
+		return fmt.Errorf("%s does not exist", path)
+	case err == nil && tk.MustNotExist:
+		return fmt.Errorf("%s already exists", path)
+	case err == nil && tk.PathKind == PathFile && info.IsDir():
+		return fmt.Errorf("%s is a folder, not a file", path)
+	case err == nil && tk.PathKind == PathDir && !info.IsDir():
+		return fmt.Errorf("%s is a file, not a folder", path)
+	}
+	return nil
+}
+
+// checksFiles reports whether checking the token's value looks at the file system.
+func (tk Token) checksFiles() bool {
+	return tk.IsPath && (tk.MustExist || tk.MustNotExist || tk.PathKind != "")
+}
+
+// formatPath expands the path and converts it to the token's path form.
+// The value should already have passed Check.
+func (tk Token) formatPath(value string) string {
+	path, err := expandPath(value)
+	if err != nil || path == "" {
+		return value
+	}
+	switch tk.PathForm {
+	case PathAbsolute:
+		return tk.absPath(path)
+	case PathProject:
+		if rel, err := filepath.Rel(tk.projectDir, tk.absPath(path)); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// absPath returns the absolute form of the path, with relative paths based on the token's relativeTo dir.
+func (tk Token) absPath(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	base := tk.projectDir
+	if tk.RelativeTo != PathProject || base == "" {
+		base, _ = os.Getwd()
+	}
+	return filepath.Join(base, path)
+}
+
+// pathRule describes the rules for a path token's value.
+func (tk Token) pathRule() string {
+	kind := "path"
+	switch tk.PathKind {
+	case PathFile:
+		kind = "file"
+	case PathDir:
+		kind = "folder"
+	}
+	rule := "a valid " + kind
+	if tk.MustExist {
+		rule = "an existing " + kind
+	} else if tk.MustNotExist {
+		rule = "a " + kind + " that does not exist yet"
+	}
+	if tk.RelativeTo == PathProject {
+		rule += " relative to the project"
+	}
+	return rule
+}
+
+// validatePath checks that the token's path options have known values and fit together.
+func (tk Token) validatePath() []error {
+	errs := []error{}
+	pathOptions := tk.MustExist || tk.MustNotExist || tk.PathKind != "" || tk.RelativeTo != "" || tk.PathForm != ""
+	if pathOptions && !tk.IsPath {
+		errs = append(errs, fmt.Errorf("token %s: path options need isPath", tk.Name))
+	}
+	if tk.MustExist && tk.MustNotExist {
+		errs = append(errs, fmt.Errorf("token %s: mustExist and mustNotExist cannot both be set", tk.Name))
+	}
+	if tk.PathKind != "" && tk.PathKind != PathFile && tk.PathKind != PathDir {
+		errs = append(errs, fmt.Errorf("token %s: pathKind must be %s or %s", tk.Name, PathFile, PathDir))
+	}
+	if tk.RelativeTo != "" && tk.RelativeTo != PathCwd && tk.RelativeTo != PathProject {
+		errs = append(errs, fmt.Errorf("token %s: relativeTo must be %s or %s", tk.Name, PathCwd, PathProject))
+	}
+	if tk.PathForm != "" && tk.PathForm != PathAbsolute && tk.PathForm != PathProject {
+		errs = append(errs, fmt.Errorf("token %s: pathForm must be %s or %s", tk.Name, PathAbsolute, PathProject))
+	}
+	return errs
+}
+
+// expandPath replaces a leading ~ with the home dir and $VAR or ${VAR} with environment variables.
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	}
+	unset := []string{}
+	path = os.Expand(path, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return value
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("path uses $%s, which is not set", unset[0])
+	}
+	return path, nil
+}
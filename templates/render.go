@@ -0,0 +1,262 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// escapeChar before an open delimiter stops a placeholder or block tag from being replaced.
+// The escape char is removed and the rest is written as is. Before anything that would not
+// be replaced anyway, such as an undefined token, the escape char is kept.
+const escapeChar = `\`
+
+// Delimiters are the strings that surround placeholders.
+type Delimiters struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// contentDelimiters returns the delimiters for placeholders in file contents, ${ and } by default.
+func (t *Template) contentDelimiters() (string, string) {
+	if t.Delimiters.Open == "" || t.Delimiters.Close == "" {
+		return "${", "}"
+	}
+	return t.Delimiters.Open, t.Delimiters.Close
+}
+
+// pathDelimiters returns the delimiters for placeholders in file and dir names, % and % by default.
+func (t *Template) pathDelimiters() (string, string) {
+	if t.PathDelimiters.Open == "" || t.PathDelimiters.Close == "" {
+		return "%", "%"
+	}
+	return t.PathDelimiters.Open, t.PathDelimiters.Close
+}
+
+// placeholder is a parsed token reference such as NAME|snake|upper.
+type placeholder struct {
+	name    string
+	filters []filterCall
+}
+
+// filterCall is a single filter in a placeholder, with its optional argument.
+type filterCall struct {
+	name string
+	arg  string
+}
+
+// replaceTokens replaces each placeholder between the open and close delimiters with its filtered token value.
+// Anything that is not a reference to a defined token is left as is.
+func replaceTokens(text, open, close string, tokens map[string]string) (string, error) {
+	return expandPlaceholders(text, open, close, func(name string) (string, bool, error) {
+		value, found := lookupToken(name, tokens)
+		return value, found, nil
+	})
+}
+
+// lookupFunc returns the value of the named token and whether it was found.
+type lookupFunc func(name string) (string, bool, error)
+
+// expandPlaceholders replaces each placeholder between the open and close delimiters
+// with the filtered value returned by lookup. Placeholders that lookup does not find are left as is.
+func expandPlaceholders(text, open, close string, lookup lookupFunc) (string, error) {
+	var sb strings.Builder
+	for {
+		start := strings.Index(text, open)
+		if start < 0 {
+			sb.WriteString(text)
+			return sb.String(), nil
+		}
+		escaped := isEscaped(text[:start])
+		sb.WriteString(text[:start])
+		text = text[start+len(open):]
+
+		end := findClose(text, close)
+		if end < 0 {
+			sb.WriteString(open)
+			continue
+		}
+		p, ok, err := parsePlaceholder(text[:end])
+		if !ok {
+			sb.WriteString(open)
+			continue
+		}
+		if escaped {
+			// Only drop the escape char when the placeholder would otherwise be replaced,
+			// so that text such as a shell script's \${HOME} is left exactly as written.
+			if _, found, err := lookup(p.name); err == nil && found {
+				output := strings.TrimSuffix(sb.String(), escapeChar)
+				sb.Reset()
+				sb.WriteString(output)
+			}
+			sb.WriteString(open + text[:end+len(close)])
+			text = text[end+len(close):]
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s%s%s: %w", open, text[:end], close, err)
+		}
+		value, found, err := lookup(p.name)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			sb.WriteString(open)
+			continue
+		}
+		value, err = p.applyFilters(value)
+		if err != nil {
+			return "", fmt.Errorf("%s%s%s: %w", open, text[:end], close, err)
+		}
+		sb.WriteString(value)
+		text = text[end+len(close):]
+	}
+}
+
+// undefinedRef is a placeholder for a token that has no value.
+type undefinedRef struct {
+	name string
+	line int
+}
+
+// findUndefined returns the placeholders in text for tokens that are not defined.
+// The item and index names of each blocks count as defined.
+func findUndefined(text, open, close string, tokens map[string]string) []undefinedRef {
+	local := map[string]bool{}
+	for _, tag := range findBlockTags(text, open, close) {
+		name, arg, _ := strings.Cut(tag.content, " ")
+		if name == "#each" {
+			_, item, found := strings.Cut(arg, " as ")
+			if !found {
+				item = "ITEM"
+			}
+			local[strings.TrimSpace(item)] = true
+			local["INDEX"] = true
+		}
+	}
+
+	refs := []undefinedRef{}
+	pos := 0
+	for {
+		start := strings.Index(text[pos:], open)
+		if start < 0 {
+			return refs
+		}
+		start += pos
+		pos = start + len(open)
+		end := findClose(text[pos:], close)
+		if end < 0 || isEscaped(text[:start]) {
+			continue
+		}
+		if isBlockTag(strings.TrimSpace(text[pos : pos+end])) {
+			pos += end + len(close)
+			continue
+		}
+		p, ok, _ := parsePlaceholder(text[pos : pos+end])
+		if !ok {
+			continue
+		}
+		if isDataName(p.name) && !hasDataFile(p.name, tokens) {
+			// Not a reference to one of the template's data files, as in a JavaScript ${data.items}.
+			pos += end + len(close)
+			continue
+		}
+		if _, found := lookupToken(p.name, tokens); !found && !local[p.name] {
+			refs = append(refs, undefinedRef{p.name, strings.Count(text[:start], "\n") + 1})
+		}
+		pos += end + len(close)
+	}
+}
+
+// isEscaped reports whether the text before an open delimiter ends with the escape char.
+func isEscaped(before string) bool {
+	return strings.HasSuffix(before, escapeChar)
+}
+
+// findClose returns the index of the close delimiter, skipping over quoted filter arguments.
+// It returns -1 if there is no close delimiter before the end of the line.
+func findClose(text, close string) int {
+	inQuote := false
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+			return -1
+		case inQuote && text[i] == '\\':
+			i++
+		case text[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && strings.HasPrefix(text[i:], close):
+			return i
+		}
+	}
+	return -1
+}
+
+// parsePlaceholder parses the text inside the delimiters. ok is false if it is not a token reference.
+// An unknown filter name is an error, whether or not the token is defined.
+func parsePlaceholder(text string) (p placeholder, ok bool, err error) {
+	parts := splitUnquoted(text, '|')
+	p.name = strings.TrimSpace(parts[0])
+	if !isTokenName(p.name) && !isDynamicName(p.name) && !isDataName(p.name) {
+		return p, false, nil
+	}
+	for _, part := range parts[1:] {
+		name, arg, _ := strings.Cut(part, ":")
+		arg = strings.TrimSpace(arg)
+		if unquoted, err := strconv.Unquote(arg); err == nil {
+			arg = unquoted
+		}
+		name = strings.TrimSpace(name)
+		if _, found := filters[name]; !found && err == nil {
+			err = fmt.Errorf("unknown filter %q", name)
+		}
+		p.filters = append(p.filters, filterCall{name, arg})
+	}
+	return p, true, err
+}
+
+// applyFilters runs the value through each of the placeholder's filters in turn.
+func (p placeholder) applyFilters(value string) (string, error) {
+	for _, f := range p.filters {
+		var err error
+		value, err = applyFilter(f.name, f.arg, value)
+		if err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// splitUnquoted splits text on sep, ignoring any sep inside double quotes.
+func splitUnquoted(text string, sep byte) []string {
+	parts := []string{}
+	inQuote := false
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case inQuote && text[i] == '\\':
+			i++
+		case text[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && text[i] == sep:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// isTokenName reports whether name is made of letters, digits and underscores and does not start with a digit.
+func isTokenName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !(i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
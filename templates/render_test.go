@@ -0,0 +1,84 @@
+package templates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindUndefined(t *testing.T) {
+	tokens := map[string]string{"NAME": "app", "USE_DB": "true", "SERVICES": "a\nb"}
+	tests := []struct {
+		name string
+		text string
+		want []undefinedRef
+	}{
+		{"defined", "${NAME}", []undefinedRef{}},
+		{"undefined", "a\n${TITEL}", []undefinedRef{{"TITEL", 2}}},
+		{"escaped", `\${TITEL}`, []undefinedRef{}},
+		{"if else blocks", "${#if USE_DB}\na\n${else}\nb\n${/if}", []undefinedRef{}},
+		{"each item", "${#each SERVICES as S}${S}${INDEX}${/each}", []undefinedRef{}},
+		{"inside block", "${#if USE_DB}${NOPE}${/if}", []undefinedRef{{"NOPE", 1}}},
+		{"shell syntax", "${#items[@]} ${x/y/z}", []undefinedRef{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findUndefined(tt.text, "${", "}", tokens)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findUndefined(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceTokens(t *testing.T) {
+	tokens := map[string]string{"NAME": "My App", "DIR": `C:\src\`}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "${NAME}", "My App"},
+		{"filters", "${NAME|kebab|upper}", "MY-APP"},
+		{"filter arg", `${NAME|join:"-"}`, "My App"},
+		{"undefined left", "${NOPE}", "${NOPE}"},
+		{"escaped", `\${NAME}`, "${NAME}"},
+		{"escaped undefined", `\${NOPE}`, `\${NOPE}`},
+		{"escaped shell variable", `echo "\${HOME}"`, `echo "\${HOME}"`},
+		{"escaped js literal", "`\\${x}`", "`\\${x}`"},
+		{"value ending in escape char", "${DIR}${NAME}", `C:\src\My App`},
+		{"unclosed", "${NAME", "${NAME"},
+		{"escaped unknown filter", `\${NAME|bogus}`, "${NAME|bogus}"},
+		{"not a token", "${a b}", "${a b}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replaceTokens(tt.text, "${", "}", tokens)
+			if err != nil {
+				t.Fatalf("replaceTokens(%q) error: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("replaceTokens(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceTokensErrors(t *testing.T) {
+	tokens := map[string]string{"NAME": "app"}
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"unknown filter", "${NAME|bogus}"},
+		{"unknown filter on undefined token", "${UNDEF|bogus}"},
+		{"unknown filter after known one", "${NAME|upper|bogus}"},
+		{"date without layout", "${NAME|date}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := replaceTokens(tt.text, "${", "}", tokens); err == nil {
+				t.Errorf("replaceTokens(%q) gave no error", tt.text)
+			}
+		})
+	}
+}
@@ -15,42 +15,96 @@ import (
 	"github.com/bit101/tinfox/theme"
 )
 
-// Token describes a single token.
-type Token struct {
-	Name       string `json:"name"`
-	Default    string `json:"default"`
-	IsPath     bool   `json:"isPath"`
-	IsRequired bool   `json:"required"`
-}
-
 // Template is a struct holding template data.
 type Template struct {
-	Name              string   `json:"name"`
-	Description       string   `json:"description"`
-	Tokens            []Token  `json:"tokens"`
-	PreMessage        string   `json:"preMessage"`
-	PostMessage       string   `json:"postMessage"`
-	Ignore            []string `json:"ignore"`
+	Name              string            `json:"name"`
+	Description       string            `json:"description"`
+	Tokens            []Token           `json:"tokens"`
+	Derived           map[string]string `json:"derived"`
+	Validations       []Validation      `json:"validations"`
+	PreMessage        string            `json:"preMessage"`
+	PostMessage       string            `json:"postMessage"`
+	Ignore            []string          `json:"ignore"`
+	Include           map[string]string `json:"include"`
+	Raw               []string          `json:"raw"`
+	DataDir           string            `json:"dataDir"`
+	Delimiters        Delimiters        `json:"delimiters"`
+	PathDelimiters    Delimiters        `json:"pathDelimiters"`
 	TemplateSourceDir string
 	ProjectDir        string
 	TokenValues       map[string]string
+	ignoreList        ignoreList
+	rawList           ignoreList
 }
 
 // TemplateParser reads and parses a template.
 type TemplateParser struct {
-	template *Template
+	template    *Template
+	presets     map[string]string
+	answers     *Answers
+	interactive bool
+	strict      bool
 }
 
 // NewTemplateParser creates a new TemplateParser.
 func NewTemplateParser() *TemplateParser {
 	config.LoadConfig()
-	return &TemplateParser{}
+	return &TemplateParser{
+		presets:     map[string]string{},
+		answers:     &Answers{},
+		interactive: true,
+	}
+}
+
+// SetInteractive sets whether the user can be prompted for values that were not supplied.
+func (t *TemplateParser) SetInteractive(interactive bool) {
+	t.interactive = interactive
+}
+
+// SetStrict sets whether placeholders of undefined tokens stop the project from being created.
+func (t *TemplateParser) SetStrict(strict bool) {
+	t.strict = strict
+}
+
+// SetTokenValue presets the value of a token so that it will not be prompted for.
+func (t *TemplateParser) SetTokenValue(name, value string) {
+	t.presets[name] = value
+}
+
+// UseAnswers loads an answers file whose values are used instead of prompting.
+// Values set with SetTokenValue take precedence over values in the file.
+func (t *TemplateParser) UseAnswers(path string) {
+	answers, err := LoadAnswers(path)
+	if err != nil {
+		theme.PrintErrorln("Could not load answers file:", err)
+		os.Exit(1)
+	}
+	t.answers = answers
+}
+
+// AnswersTemplate returns the template named in the answers file, if any.
+func (t *TemplateParser) AnswersTemplate() string {
+	return t.answers.Template
+}
+
+// AnswersProjectDir returns the project dir named in the answers file, if any.
+func (t *TemplateParser) AnswersProjectDir() string {
+	return t.answers.ProjectDir
 }
 
 // LoadAndParse loads the template list, gets the user's choice, dir, tokens values and creates the project.
+// The template and dir are taken from the answers file when it has them.
 func (t *TemplateParser) LoadAndParse() {
-	t.GetTemplateChoice()
-	t.GetProjectDir()
+	if t.answers.Template != "" {
+		t.UseTemplate(t.answers.Template)
+	} else {
+		t.GetTemplateChoice()
+	}
+	if t.answers.ProjectDir != "" {
+		t.SetProjectDir(t.answers.ProjectDir)
+	} else {
+		t.GetProjectDir()
+	}
 	t.DefineTokens()
 	t.CreateProject()
 	t.ShowSuccess()
@@ -74,6 +128,104 @@ func (t *TemplateParser) GetTemplateChoice() {
 	t.DisplayChoice()
 }
 
+// UseTemplate selects a template by name or directory instead of showing the template ui.
+func (t *TemplateParser) UseTemplate(nameOrDir string) {
+	template, err := t.FindTemplate(nameOrDir)
+	if err != nil {
+		theme.PrintErrorln(err)
+		fmt.Println("  Use `tinfox list` to see the available templates.")
+		os.Exit(1)
+	}
+	t.template = template
+	t.DisplayChoice()
+}
+
+// DisplayInfo shows info about a template given by name or directory, including the tokens it asks for.
+func (t *TemplateParser) DisplayInfo(nameOrDir string) {
+	template, err := t.FindTemplate(nameOrDir)
+	if err != nil {
+		theme.PrintErrorln(err)
+		os.Exit(1)
+	}
+	theme.PrintHeaderln("Project Info:")
+	theme.PrintInstruction("Project: ")
+	fmt.Println(template.Name)
+	theme.PrintInstruction("Description: ")
+	fmt.Println(template.Description)
+	theme.PrintInstruction("Location: ")
+	fmt.Println(template.TemplateSourceDir)
+	if template.PreMessage != "" {
+		theme.PrintInstruction("Message: ")
+		fmt.Println(template.PreMessage)
+	}
+	if len(template.Tokens) == 0 {
+		return
+	}
+	fmt.Println()
+	theme.PrintHeaderln("Tokens:")
+	for _, token := range template.Tokens {
+		theme.PrintInstructionln(token.Name)
+		if token.Group != "" {
+			fmt.Printf("  Group: %s\n", token.Group)
+		}
+		if token.Prompt != "" {
+			fmt.Printf("  Prompt: %s\n", token.Prompt)
+		}
+		if token.Help != "" {
+			fmt.Printf("  Help: %s\n", strings.ReplaceAll(token.Help, "\n", "\n        "))
+		}
+		if token.Type != "" {
+			fmt.Printf("  Type: %s\n", token.Type)
+		}
+		if token.Type == TypeList && token.Default != "" {
+			fmt.Printf("  Default: %s\n", strings.Join(parseList(token.Default), ", "))
+		} else if token.Default != "" {
+			fmt.Printf("  Default: %s\n", token.Display(token.Default))
+		}
+		if len(token.Suggestions) > 0 {
+			fmt.Printf("  Suggestions: %s\n", strings.Join(token.Suggestions, ", "))
+		}
+		if token.DefaultCommand != "" {
+			fmt.Printf("  Default command: %s\n", token.DefaultCommand)
+		}
+		if token.DefaultFile != "" {
+			fmt.Printf("  Default file: %s\n", token.DefaultFile)
+		}
+		if rules := token.Rules(); len(rules) > 0 {
+			fmt.Printf("  Rules: %s\n", strings.Join(rules, ", "))
+		}
+	}
+	if len(template.Validations) > 0 {
+		fmt.Println()
+		theme.PrintHeaderln("Validations:")
+		for _, v := range template.Validations {
+			theme.PrintInstructionln(v.Rule)
+			if v.Message != "" {
+				fmt.Printf("  %s\n", v.Message)
+			}
+		}
+	}
+}
+
+// ValidateTemplate checks a template given by name or directory and displays any problems found.
+func (t *TemplateParser) ValidateTemplate(nameOrDir string) {
+	template, err := t.FindTemplate(nameOrDir)
+	if err != nil {
+		theme.PrintErrorln(err)
+		os.Exit(1)
+	}
+	errs := template.Validate()
+	if len(errs) == 0 {
+		theme.PrintHeaderf("No problems found in the %q template.\n", template.Name)
+		return
+	}
+	theme.PrintErrorf("Found %d problem(s) in the %q template:\n", len(errs), template.Name)
+	for _, err := range errs {
+		fmt.Printf("  %s\n", err)
+	}
+	os.Exit(1)
+}
+
 // DisplayList displays the list of available templates.
 func (t *TemplateParser) DisplayList() {
 	list := t.GetTemplateList()
@@ -116,9 +268,34 @@ func (t *TemplateParser) GetTemplateList() []*Template {
 	return list
 }
 
+// FindTemplate returns the template in the given directory, or the template with the given dir name or name.
+func (t *TemplateParser) FindTemplate(nameOrDir string) (*Template, error) {
+	info, err := os.Stat(nameOrDir)
+	if err == nil && info.IsDir() {
+		absDir, _ := filepath.Abs(nameOrDir)
+		template, err := loadTemplateDir(absDir)
+		if err == nil {
+			return template, nil
+		}
+	}
+	template, err := t.LoadTemplate(nameOrDir)
+	if err == nil {
+		return template, nil
+	}
+	for _, template := range t.GetTemplateList() {
+		if strings.EqualFold(template.Name, nameOrDir) {
+			return template, nil
+		}
+	}
+	return nil, fmt.Errorf("No template found for %q.", nameOrDir)
+}
+
 // LoadTemplate loads, parses and returns the template.
 func (t *TemplateParser) LoadTemplate(name string) (*Template, error) {
-	templateSourceDir := filepath.Join(config.ActiveConfig.TemplatesDir, name)
+	return loadTemplateDir(filepath.Join(config.ActiveConfig.TemplatesDir, name))
+}
+
+func loadTemplateDir(templateSourceDir string) (*Template, error) {
 	templateStr, err := os.ReadFile(filepath.Join(templateSourceDir, "template.json"))
 	if err != nil {
 		return nil, err
@@ -130,22 +307,194 @@ func (t *TemplateParser) LoadTemplate(name string) (*Template, error) {
 }
 
 // DefineTokens gets values for all the tokens and stores the values in the template.
+// Preset values and answers file values are used as is.
+// Other tokens are prompted for, or take their defaults when not interactive.
+// Defaults and askIf conditions can reference built-in tokens and the tokens defined before them.
 func (t *TemplateParser) DefineTokens() {
-	if len(t.template.Tokens) == 0 {
-		return
+	answerValues := t.answers.TokenValues()
+	for name := range t.presets {
+		if t.template.findToken(name) == nil {
+			theme.PrintErrorf("The %q template has no token named %q.\n", t.template.Name, name)
+			os.Exit(1)
+		}
 	}
-	if config.ActiveConfig.Verbose {
-		theme.PrintHeaderln("Define values for any tokens:")
+
+	for i := range t.template.Tokens {
+		t.template.Tokens[i].projectDir = t.template.ProjectDir
 	}
 	tokenValues := map[string]string{}
+	t.template.TokenValues = tokenValues
+	t.template.defineBuiltins()
+	if err := t.template.defineData(); err != nil {
+		theme.PrintErrorf("Template error: %s\n", err)
+		os.Exit(1)
+	}
+	missing := []string{}
+	prompted := false
+	group := ""
 	for _, token := range t.template.Tokens {
-		value := clui.ReadToken(token.Name, token.Default, token.IsRequired, token.IsPath)
-		tokenValues[token.Name] = value
+		def, err := t.template.resolveDefault(token)
+		if err != nil {
+			theme.PrintErrorf("Template error: %s\n", err)
+			os.Exit(1)
+		}
+		token.Default = def
+
+		value, ok := t.presets[token.Name]
+		if !ok {
+			value, ok = answerValues[token.Name]
+		}
+		if ok {
+			if err := token.Check(value); err != nil {
+				theme.PrintErrorf("Invalid value for %s: %s\n", token.Name, err)
+				os.Exit(1)
+			}
+			tokenValues[token.Name] = token.Normalize(value)
+			continue
+		}
+		if token.AskIf != "" {
+			ask, err := evalCondition(token.AskIf, tokenValues)
+			if err != nil {
+				theme.PrintErrorf("Template error: askIf for %s: %s\n", token.Name, err)
+				os.Exit(1)
+			}
+			if !ask {
+				tokenValues[token.Name] = token.Normalize(token.Default)
+				continue
+			}
+		}
+		// Default commands only run for tokens that are asked for, or need their default when not interactive.
+		token.Default = sourcedDefault(token)
+		if !t.interactive {
+			if err := token.Check(token.Default); err != nil && token.Default == "" {
+				missing = append(missing, token.Name)
+			} else if err != nil {
+				theme.PrintErrorf("Invalid default for %s: %s\n", token.Name, err)
+				os.Exit(1)
+			}
+			tokenValues[token.Name] = token.Normalize(token.Default)
+			continue
+		}
+		if !prompted && config.ActiveConfig.Verbose {
+			theme.PrintHeaderln("Define values for any tokens:")
+		}
+		prompted = true
+		if token.Group != "" && token.Group != group {
+			fmt.Println()
+			theme.PrintHeaderln(token.Group)
+		}
+		group = token.Group
+		tokenValues[token.Name] = token.Normalize(promptToken(token))
+	}
+	if len(missing) > 0 {
+		theme.PrintErrorf("Missing values for required tokens: %s\n", strings.Join(missing, ", "))
+		fmt.Println("  Supply them with --set TOKEN=value or in a --values file.")
+		os.Exit(1)
+	}
+	if err := t.template.defineDerived(); err != nil {
+		theme.PrintErrorf("Template error: %s\n", err)
+		os.Exit(1)
+	}
+	t.applyValidations()
+	if prompted {
+		fmt.Println()
 	}
-	tokenValues["PROJECT_PATH"] = t.template.ProjectDir
-	tokenValues["PROJECT_DIR"] = filepath.Base(t.template.ProjectDir)
-	t.template.TokenValues = tokenValues
-	fmt.Println()
+}
+
+// applyValidations checks the template's validations against the token values.
+// When interactive, the tokens behind any failed validation are asked for again until all pass.
+// Otherwise every failed validation is listed and tinfox exits.
+func (t *TemplateParser) applyValidations() {
+	for {
+		failed, err := t.template.failedValidations()
+		if err != nil {
+			theme.PrintErrorf("Template error: %s\n", err)
+			os.Exit(1)
+		}
+		if len(failed) == 0 {
+			return
+		}
+		if !t.interactive {
+			theme.PrintErrorln("Token values break the template's validation rules:")
+			for _, v := range failed {
+				fmt.Printf("  %s\n", v.Display())
+			}
+			os.Exit(1)
+		}
+
+		names := []string{}
+		for _, v := range failed {
+			theme.PrintErrorln(v.Display())
+			for _, name := range t.template.validationTokens(v) {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+		if len(names) == 0 {
+			theme.PrintErrorln("Template error: no token can be changed to fix this.")
+			os.Exit(1)
+		}
+		for _, token := range t.template.Tokens {
+			if slices.Contains(names, token.Name) {
+				token.Default = t.template.TokenValues[token.Name]
+				t.template.TokenValues[token.Name] = token.Normalize(promptToken(token))
+			}
+		}
+		t.template.clearDerived()
+		if err := t.template.defineDerived(); err != nil {
+			theme.PrintErrorf("Template error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// promptToken asks the user for the value of a single token in the way that suits its type.
+func promptToken(token Token) string {
+	label := token.Label()
+	help := token.Help
+	if config.ActiveConfig.Verbose {
+		if help != "" {
+			clui.ShowHelp(help)
+		}
+		if rules := token.Rules(); len(rules) > 0 {
+			theme.PrintDefaultf("  %s: %s\n", token.Name, strings.Join(rules, ", "))
+		}
+	} else if help != "" {
+		label += " (? for help)"
+	}
+
+	if len(token.Choices) > 0 {
+		labels := []string{}
+		for _, choice := range token.Choices {
+			labels = append(labels, choice.Display())
+		}
+		index, _ := clui.MultiChoiceDefault(labels, "Choose a value for "+label+":", token.ChoiceIndex(token.Default), help)
+		theme.PrintInstructionf("%s: ", token.Label())
+		fmt.Println(labels[index])
+		return token.Choices[index].Value
+	}
+	if token.Type == TypeBool {
+		return boolString(clui.ReadBool(label, isTruthy(token.Normalize(token.Default)), help))
+	}
+	if token.Type == TypeList {
+		def := parseList(token.Default)
+		for {
+			items := clui.ReadList(label, def, help)
+			if !token.IsRequired || len(items) > 0 {
+				return strings.Join(items, "\n")
+			}
+			theme.PrintErrorln("List cannot be empty.")
+		}
+	}
+	def := token.Default
+	if token.Check(def) == nil {
+		def = token.Normalize(def)
+	}
+	if token.Secret {
+		return clui.ReadSecretToken(label, def, help, token.Check)
+	}
+	return clui.ReadToken(label, def, help, token.allSuggestions(), token.Check)
 }
 
 // GetProjectDir requests the project directory from the user and stores it in the template.
@@ -206,6 +555,24 @@ func (t *TemplateParser) GetProjectDir() {
 	fmt.Println()
 }
 
+// SetProjectDir checks the given project directory and stores it in the template.
+func (t *TemplateParser) SetProjectDir(dir string) {
+	if dir == "" {
+		theme.PrintErrorln("Directory name cannot be empty.")
+		os.Exit(1)
+	}
+	if c, found := findInvalidPathChar(dir); found {
+		theme.PrintErrorf("Directory name cannot contain %q.\n", c)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		theme.PrintErrorf("Something already exists at location %q.\n", dir)
+		os.Exit(1)
+	}
+	absDir, _ := filepath.Abs(dir)
+	t.template.ProjectDir = absDir
+}
+
 // ShowSuccess shows the success message and any post message.
 func (t *TemplateParser) ShowSuccess() {
 	theme.PrintHeaderf("Success creating the %q project!\n", t.template.Name)
@@ -218,26 +585,86 @@ func (t *TemplateParser) ShowSuccess() {
 	fmt.Println()
 }
 
+// projectFile is a file or dir that will be written to the project.
+type projectFile struct {
+	path  string
+	mode  os.FileMode
+	isDir bool
+	data  []byte
+}
+
 // CreateProject creates the project dir, copies the files and updates the tokens.
+// All files are prepared before anything is written, so template errors leave nothing behind.
 func (t *TemplateParser) CreateProject() {
 	templateFiles, err := os.ReadDir(t.template.TemplateSourceDir)
 	if err != nil {
 		log.Fatal(err)
 	}
-	os.Mkdir(t.template.ProjectDir, 0755)
+	t.template.ignoreList, err = t.template.loadIgnoreList()
+	if err != nil {
+		t.templateError(filepath.Join(t.template.TemplateSourceDir, "template.json"), err)
+	}
+	t.template.rawList, err = parseIgnore(t.template.Raw)
+	if err != nil {
+		t.templateError(filepath.Join(t.template.TemplateSourceDir, "template.json"), err)
+	}
+
+	files := []projectFile{}
+	warnings := []string{}
 	for _, file := range templateFiles {
-		if file.Name() != "template.json" {
-			if !slices.Contains(t.template.Ignore, file.Name()) {
-				t.copyFile(file, t.template.TemplateSourceDir, t.template.ProjectDir)
-			}
+		if file.Name() != "template.json" && file.Name() != ignoreFileName {
+			t.prepareFile(file, t.template.TemplateSourceDir, t.template.ProjectDir, &files, &warnings)
+		}
+	}
+	for _, warning := range warnings {
+		theme.PrintError("Warning: ")
+		fmt.Println(warning)
+	}
+	if len(warnings) > 0 && t.strict {
+		theme.PrintErrorln("Stopping because of --strict. Nothing was written.")
+		os.Exit(1)
+	}
+
+	os.Mkdir(t.template.ProjectDir, 0755)
+	for _, file := range files {
+		if file.isDir {
+			os.Mkdir(file.path, file.mode)
+		} else {
+			os.WriteFile(file.path, file.data, file.mode)
 		}
 	}
+	// The history only improves later prompts, so failing to save it does not fail the project.
+	t.template.saveHistory()
 }
 
-func (t *TemplateParser) copyFile(file os.DirEntry, srcDir, dstDir string) {
+// prepareFile adds a file, or a dir and its contents, to the files to be written,
+// and adds a warning for each placeholder of an undefined token.
+func (t *TemplateParser) prepareFile(file os.DirEntry, srcDir, dstDir string, files *[]projectFile, warnings *[]string) {
 	srcFilePath := filepath.Join(srcDir, file.Name())
-	dstFilePath := filepath.Join(dstDir, file.Name())
-	dstFilePath = replaceDirTokens(dstFilePath, t.template.TokenValues)
+	relPath, err := filepath.Rel(t.template.TemplateSourceDir, srcFilePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	relPath = filepath.ToSlash(relPath)
+	if t.template.ignoreList.matches(relPath, file.IsDir()) {
+		return
+	}
+	included, err := t.template.isIncluded(relPath)
+	if err != nil {
+		t.templateError(srcFilePath, err)
+	}
+	if !included {
+		return
+	}
+	dstName, err := t.template.replaceDirTokens(file.Name())
+	if err != nil {
+		t.templateError(srcFilePath, err)
+	}
+	open, close := t.template.pathDelimiters()
+	for _, ref := range findUndefined(file.Name(), open, close, t.template.TokenValues) {
+		*warnings = append(*warnings, fmt.Sprintf("%s: undefined token %s in name", relPath, ref.name))
+	}
+	dstFilePath := filepath.Join(dstDir, dstName)
 
 	fileInfo, err := file.Info()
 	if err != nil {
@@ -246,37 +673,47 @@ func (t *TemplateParser) copyFile(file os.DirEntry, srcDir, dstDir string) {
 	mode := fileInfo.Mode()
 
 	if file.IsDir() {
-		os.Mkdir(dstFilePath, mode)
-		files, err := os.ReadDir(srcFilePath)
+		*files = append(*files, projectFile{path: dstFilePath, mode: mode, isDir: true})
+		subFiles, err := os.ReadDir(srcFilePath)
 		if err != nil {
 			log.Fatal(err)
 		}
-		for _, subFile := range files {
-			t.copyFile(subFile, srcFilePath, dstFilePath)
+		for _, subFile := range subFiles {
+			t.prepareFile(subFile, srcFilePath, dstFilePath, files, warnings)
 		}
 	} else {
 		fileData, err := os.ReadFile(srcFilePath)
 		if err != nil {
 			log.Fatal(err)
 		}
-		fileData = replaceFileTokens(fileData, t.template.TokenValues)
-		os.WriteFile(dstFilePath, fileData, mode)
+		if !t.template.rawList.matchesFile(relPath) && !isBinary(file.Name(), fileData) {
+			open, close := t.template.contentDelimiters()
+			for _, ref := range findUndefined(string(fileData), open, close, t.template.TokenValues) {
+				*warnings = append(*warnings, fmt.Sprintf("%s:%d: undefined token %s", relPath, ref.line, ref.name))
+			}
+			fileData, err = t.template.replaceFileTokens(fileData)
+			if err != nil {
+				t.templateError(srcFilePath, err)
+			}
+		}
+		*files = append(*files, projectFile{path: dstFilePath, mode: mode, data: fileData})
 	}
 }
 
-func replaceFileTokens(fileData []byte, tokens map[string]string) []byte {
-	text := string(fileData)
-	for token, value := range tokens {
-		token = "${" + token + "}"
-		text = strings.ReplaceAll(text, token, value)
-	}
-	return []byte(text)
+// templateError reports an error in a template file and exits.
+func (t *TemplateParser) templateError(path string, err error) {
+	relPath, _ := filepath.Rel(t.template.TemplateSourceDir, path)
+	theme.PrintErrorf("Template error in %s: %s\n", relPath, err)
+	os.Exit(1)
 }
 
-func replaceDirTokens(path string, tokens map[string]string) string {
-	for token, value := range tokens {
-		token = "%" + token + "%"
-		path = strings.ReplaceAll(path, token, value)
-	}
-	return path
+func (t *Template) replaceFileTokens(fileData []byte) ([]byte, error) {
+	open, close := t.contentDelimiters()
+	text, err := renderContent(string(fileData), open, close, t.TokenValues)
+	return []byte(text), err
+}
+
+func (t *Template) replaceDirTokens(name string) (string, error) {
+	open, close := t.pathDelimiters()
+	return replaceTokens(name, open, close, t.TokenValues)
 }
@@ -0,0 +1,395 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bit101/tinfox/config"
+)
+
+// Token types. Tokens without a type are strings.
+const (
+	TypeString = "string"
+	TypeBool   = "bool"
+	TypeList   = "list"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+)
+
+// Token describes a single token.
+type Token struct {
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+	Help   string `json:"help"`
+	Group  string `json:"group"`
+	// AskIf is a condition on earlier tokens. When it is false the token is not asked for and takes its default.
+	AskIf   string `json:"askIf"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	// DefaultCommand and DefaultFile give a default from a shell command's output or a file's contents.
+	// When they fail, the static default is used.
+	DefaultCommand string   `json:"defaultCommand"`
+	DefaultFile    string   `json:"defaultFile"`
+	IsPath         bool     `json:"isPath"`
+	IsRequired     bool     `json:"required"`
+	Choices        []Choice `json:"choices"`
+	// Suggestions are common values offered with tab completion. Other values are still allowed.
+	Suggestions []string `json:"suggestions"`
+	// MustExist, MustNotExist, PathKind, RelativeTo and PathForm refine isPath tokens.
+	// Relative paths are based on RelativeTo, "cwd" or "project", and PathForm
+	// converts values to "absolute" or "project" relative paths.
+	MustExist    bool   `json:"mustExist"`
+	MustNotExist bool   `json:"mustNotExist"`
+	PathKind     string `json:"pathKind"`
+	RelativeTo   string `json:"relativeTo"`
+	PathForm     string `json:"pathForm"`
+	// Pattern is a regular expression that the whole value must match.
+	Pattern      string `json:"pattern"`
+	MinLength    int    `json:"minLength"`
+	MaxLength    int    `json:"maxLength"`
+	ErrorMessage string `json:"errorMessage"`
+	// Min, Max and Step constrain int and float tokens.
+	Min  *float64 `json:"min"`
+	Max  *float64 `json:"max"`
+	Step float64  `json:"step"`
+	// Secret tokens are read without echo, are masked wherever values are shown,
+	// and are never saved anywhere other than the generated project.
+	Secret bool `json:"secret"`
+	// projectDir is the project's dir, for path tokens relative to the project.
+	projectDir string
+}
+
+// Label returns the token's prompt, or its name if it has no prompt.
+func (tk Token) Label() string {
+	if tk.Prompt != "" {
+		return tk.Prompt
+	}
+	return tk.Name
+}
+
+// secretMask is shown in place of secret values.
+const secretMask = "********"
+
+// Display returns the value as it may be shown to the user, masked if the token is secret.
+func (tk Token) Display(value string) string {
+	if tk.Secret && value != "" {
+		return secretMask
+	}
+	return value
+}
+
+// UnmarshalJSON allows a token's default to be given as a bool or number as well as a string.
+func (tk *Token) UnmarshalJSON(data []byte) error {
+	type token Token
+	aux := struct {
+		*token
+		Default any `json:"default"`
+	}{token: (*token)(tk)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	tk.Default = formatValue(aux.Default)
+	return nil
+}
+
+// Choice is one of the allowed values of a token, with an optional label to show in the menu.
+type Choice struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// UnmarshalJSON allows a choice to be given as a plain string value as well as an object.
+func (c *Choice) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		c.Value = value
+		return nil
+	}
+	type choice Choice
+	return json.Unmarshal(data, (*choice)(c))
+}
+
+// Display returns the label of the choice, or its value if it has no label.
+func (c Choice) Display() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return c.Value
+}
+
+// ChoiceIndex returns the index of the choice with the given value, or -1.
+func (tk Token) ChoiceIndex(value string) int {
+	for i, choice := range tk.Choices {
+		if choice.Value == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// Check returns an error if the value is not acceptable for the token.
+func (tk Token) Check(value string) error {
+	if tk.IsRequired && value == "" {
+		return errors.New("value cannot be empty")
+	}
+	switch tk.Type {
+	case "", TypeString:
+	case TypeBool:
+		if _, ok := parseBool(value); !ok && value != "" {
+			return errors.New("value must be true or false")
+		}
+	case TypeList:
+		if tk.IsRequired && len(parseList(value)) == 0 {
+			return errors.New("list cannot be empty")
+		}
+	case TypeInt, TypeFloat:
+		if err := tk.checkNumber(value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown token type %q", tk.Type)
+	}
+	if err := tk.checkRules(value); err != nil {
+		if tk.ErrorMessage != "" {
+			return errors.New(tk.ErrorMessage)
+		}
+		return err
+	}
+	if len(tk.Choices) > 0 && tk.ChoiceIndex(value) < 0 && (tk.IsRequired || value != "") {
+		values := []string{}
+		for _, choice := range tk.Choices {
+			values = append(values, choice.Value)
+		}
+		return fmt.Errorf("value must be one of: %s", strings.Join(values, ", "))
+	}
+	if tk.IsPath {
+		return tk.checkPath(value)
+	}
+	return nil
+}
+
+// checkRules checks the value against the token's pattern and length rules. Empty values are not checked.
+func (tk Token) checkRules(value string) error {
+	if value == "" {
+		return nil
+	}
+	length := utf8.RuneCountInString(value)
+	if tk.MinLength > 0 && length < tk.MinLength {
+		return fmt.Errorf("value must be at least %d characters", tk.MinLength)
+	}
+	if tk.MaxLength > 0 && length > tk.MaxLength {
+		return fmt.Errorf("value must be at most %d characters", tk.MaxLength)
+	}
+	if tk.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + tk.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("bad pattern %q: %w", tk.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("value must match the pattern %s", tk.Pattern)
+		}
+	}
+	return nil
+}
+
+// checkNumber checks that the value is a number of the token's type, within its min, max and step. Empty values are not checked.
+func (tk Token) checkNumber(value string) error {
+	if value == "" {
+		return nil
+	}
+	var n float64
+	if tk.Type == TypeInt {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("value must be a whole number")
+		}
+		n = float64(i)
+	} else {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New("value must be a number")
+		}
+		n = f
+	}
+	if tk.Min != nil && n < *tk.Min {
+		return fmt.Errorf("value must be at least %s", formatNumber(*tk.Min))
+	}
+	if tk.Max != nil && n > *tk.Max {
+		return fmt.Errorf("value must be at most %s", formatNumber(*tk.Max))
+	}
+	if tk.Step > 0 {
+		base := 0.0
+		if tk.Min != nil {
+			base = *tk.Min
+		}
+		steps := (n - base) / tk.Step
+		if math.Abs(steps-math.Round(steps)) > 1e-9 {
+			if base == 0 {
+				return fmt.Errorf("value must be a multiple of %s", formatNumber(tk.Step))
+			}
+			return fmt.Errorf("value must be %s plus a multiple of %s", formatNumber(base), formatNumber(tk.Step))
+		}
+	}
+	return nil
+}
+
+// formatNumber formats a number without an exponent or trailing zeros.
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// Rules returns descriptions of the rules a value of the token must follow.
+func (tk Token) Rules() []string {
+	rules := []string{}
+	if tk.IsRequired {
+		rules = append(rules, "required")
+	}
+	if tk.Secret {
+		rules = append(rules, "secret")
+	}
+	if tk.IsPath {
+		rules = append(rules, tk.pathRule())
+	}
+	switch {
+	case tk.MinLength > 0 && tk.MaxLength > 0:
+		rules = append(rules, fmt.Sprintf("%d to %d characters", tk.MinLength, tk.MaxLength))
+	case tk.MinLength > 0:
+		rules = append(rules, fmt.Sprintf("at least %d characters", tk.MinLength))
+	case tk.MaxLength > 0:
+		rules = append(rules, fmt.Sprintf("at most %d characters", tk.MaxLength))
+	}
+	if tk.Pattern != "" {
+		rules = append(rules, "matches "+tk.Pattern)
+	}
+	switch tk.Type {
+	case TypeInt:
+		rules = append(rules, "a whole number")
+	case TypeFloat:
+		rules = append(rules, "a number")
+	}
+	switch {
+	case tk.Min != nil && tk.Max != nil:
+		rules = append(rules, fmt.Sprintf("from %s to %s", formatNumber(*tk.Min), formatNumber(*tk.Max)))
+	case tk.Min != nil:
+		rules = append(rules, "at least "+formatNumber(*tk.Min))
+	case tk.Max != nil:
+		rules = append(rules, "at most "+formatNumber(*tk.Max))
+	}
+	if tk.Step > 0 {
+		rules = append(rules, "in steps of "+formatNumber(tk.Step))
+	}
+	if len(tk.Choices) > 0 {
+		values := []string{}
+		for _, choice := range tk.Choices {
+			values = append(values, choice.Value)
+		}
+		rules = append(rules, "one of "+strings.Join(values, ", "))
+	}
+	return rules
+}
+
+// Normalize returns the value in the standard form for the token's type,
+// such as "true" or "false" for bools, newline separated items for lists, or expanded paths.
+// The value should already have passed Check.
+func (tk Token) Normalize(value string) string {
+	if tk.IsPath {
+		return tk.formatPath(value)
+	}
+	switch tk.Type {
+	case TypeBool:
+		b, _ := parseBool(value)
+		return boolString(b)
+	case TypeList:
+		return strings.Join(parseList(value), "\n")
+	case TypeInt:
+		if n, err := strconv.Atoi(value); err == nil {
+			return strconv.Itoa(n)
+		}
+	case TypeFloat:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return formatNumber(n)
+		}
+	}
+	return value
+}
+
+// formatValue converts a value from a JSON or YAML file to a token value string.
+// Arrays become newline separated lists.
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, formatValue(item))
+		}
+		return strings.Join(items, "\n")
+	case float64:
+		return formatNumber(value)
+	}
+	return fmt.Sprint(value)
+}
+
+// parseList splits a list value into its items. Items are separated by newlines
+// or, if the value is all on one line, by commas. Empty items are dropped.
+func parseList(value string) []string {
+	sep := ","
+	if strings.Contains(value, "\n") {
+		sep = "\n"
+	}
+	items := []string{}
+	for _, item := range strings.Split(value, sep) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listItems returns the items of a normalized list value.
+func listItems(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, "\n")
+}
+
+// parseBool parses the ways a user might write a bool value.
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "on", "1":
+		return true, true
+	case "false", "no", "n", "off", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// findToken returns the token with the given name, or nil.
+func (t *Template) findToken(name string) *Token {
+	for i := range t.Tokens {
+		if t.Tokens[i].Name == name {
+			return &t.Tokens[i]
+		}
+	}
+	return nil
+}
+
+// findInvalidPathChar returns the first character in the path that the config does not allow.
+func findInvalidPathChar(path string) (string, bool) {
+	for _, c := range config.ActiveConfig.InvalidPathChars {
+		if strings.ContainsRune(path, c) {
+			return string(c), true
+		}
+	}
+	return "", false
+}
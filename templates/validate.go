@@ -0,0 +1,202 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Validate checks the template for problems that would otherwise only show up when creating a project.
+func (t *Template) Validate() []error {
+	errs := []error{}
+	defined := slices.Clone(builtinTokens)
+	if (t.Delimiters.Open == "") != (t.Delimiters.Close == "") {
+		errs = append(errs, fmt.Errorf("delimiters need both open and close"))
+	}
+	if (t.PathDelimiters.Open == "") != (t.PathDelimiters.Close == "") {
+		errs = append(errs, fmt.Errorf("pathDelimiters need both open and close"))
+	}
+
+	data, err := t.loadData()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	checkRef := func(ref string) bool {
+		if isDataName(ref) {
+			return hasDataFile(ref, data)
+		}
+		return slices.Contains(defined, ref) || isDynamicName(ref)
+	}
+
+	open, close := t.contentDelimiters()
+	for _, token := range t.Tokens {
+		errs = append(errs, checkFilters("default for "+token.Name, token.Default, open, close)...)
+		for _, ref := range placeholderNames(token.Default, open, close) {
+			if !checkRef(ref) {
+				errs = append(errs, fmt.Errorf("default for %s references %s, which is not defined before it", token.Name, ref))
+			}
+		}
+		if token.AskIf != "" {
+			if e, err := parseExpr(token.AskIf); err != nil {
+				errs = append(errs, fmt.Errorf("askIf for %s: %w", token.Name, err))
+			} else {
+				for _, ref := range e.refs() {
+					if !slices.Contains(defined, ref) {
+						errs = append(errs, fmt.Errorf("askIf for %s references %s, which is not defined before it", token.Name, ref))
+					}
+				}
+			}
+		}
+		if !isTokenName(token.Name) {
+			errs = append(errs, fmt.Errorf("token name %q can only contain letters, digits and underscores", token.Name))
+		}
+		if slices.Contains(builtinTokens, token.Name) {
+			errs = append(errs, fmt.Errorf("token %s has the same name as a built-in token", token.Name))
+		} else if slices.Contains(defined, token.Name) {
+			errs = append(errs, fmt.Errorf("token %s is defined more than once", token.Name))
+		}
+		defined = append(defined, token.Name)
+		if token.Pattern != "" {
+			if _, err := regexp.Compile(token.Pattern); err != nil {
+				errs = append(errs, fmt.Errorf("token %s: bad pattern: %w", token.Name, err))
+			}
+		}
+		if token.MaxLength > 0 && token.MinLength > token.MaxLength {
+			errs = append(errs, fmt.Errorf("token %s: minLength is greater than maxLength", token.Name))
+		}
+		if token.Secret && (len(token.Choices) > 0 || token.Type == TypeBool || token.Type == TypeList) {
+			errs = append(errs, fmt.Errorf("token %s: only text and number tokens can be secret", token.Name))
+		}
+		if token.Secret && len(token.Suggestions) > 0 {
+			errs = append(errs, fmt.Errorf("token %s: secret tokens cannot have suggestions", token.Name))
+		}
+		for _, s := range token.Suggestions {
+			if err := token.Check(s); err != nil && !token.checksFiles() {
+				errs = append(errs, fmt.Errorf("token %s: suggestion %q: %w", token.Name, s, err))
+			}
+		}
+		if token.Min != nil && token.Max != nil && *token.Min > *token.Max {
+			errs = append(errs, fmt.Errorf("token %s: min is greater than max", token.Name))
+		}
+		if token.Step < 0 {
+			errs = append(errs, fmt.Errorf("token %s: step cannot be negative", token.Name))
+		}
+		errs = append(errs, token.validatePath()...)
+		if !slices.Contains([]string{"", TypeString, TypeBool, TypeList, TypeInt, TypeFloat}, token.Type) {
+			errs = append(errs, fmt.Errorf("token %s: unknown token type %q", token.Name, token.Type))
+		} else if token.Default != "" && len(placeholderNames(token.Default, open, close)) == 0 && !token.checksFiles() {
+			if err := token.Check(token.Default); err != nil {
+				errs = append(errs, fmt.Errorf("token %s: default: %w", token.Name, err))
+			}
+		}
+	}
+
+	derivedNames := []string{}
+	for name := range t.Derived {
+		derivedNames = append(derivedNames, name)
+		if slices.Contains(defined, name) {
+			errs = append(errs, fmt.Errorf("derived token %s is also defined as a token", name))
+		}
+	}
+	sort.Strings(derivedNames)
+	defined = append(defined, derivedNames...)
+	for _, name := range derivedNames {
+		errs = append(errs, checkFilters("derived token "+name, t.Derived[name], open, close)...)
+		for _, ref := range placeholderNames(t.Derived[name], open, close) {
+			if !checkRef(ref) {
+				errs = append(errs, fmt.Errorf("derived token %s references undefined token %s", name, ref))
+			}
+		}
+	}
+
+	errs = append(errs, t.validateValidations(defined)...)
+
+	if _, err := t.loadIgnoreList(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := parseIgnore(t.Raw); err != nil {
+		errs = append(errs, err)
+	}
+
+	includePaths := []string{}
+	for path := range t.Include {
+		includePaths = append(includePaths, path)
+	}
+	sort.Strings(includePaths)
+	for _, path := range includePaths {
+		if _, err := os.Stat(filepath.Join(t.TemplateSourceDir, cleanIncludePath(path))); err != nil {
+			errs = append(errs, fmt.Errorf("include path %q does not exist in the template", path))
+		}
+		errs = append(errs, checkCondition("include condition for "+path, t.Include[path], defined)...)
+	}
+	return errs
+}
+
+// checkCondition checks that a condition parses and only references defined tokens.
+func checkCondition(what, condition string, defined []string) []error {
+	e, err := parseExpr(condition)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", what, err)}
+	}
+	errs := []error{}
+	for _, ref := range e.refs() {
+		if !slices.Contains(defined, ref) {
+			errs = append(errs, fmt.Errorf("%s references undefined token %s", what, ref))
+		}
+	}
+	return errs
+}
+
+// checkFilters checks that the placeholders in text only use known filters.
+func checkFilters(what, text, open, close string) []error {
+	_, err := expandPlaceholders(text, open, close, func(name string) (string, bool, error) {
+		return "", false, nil
+	})
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", what, err)}
+	}
+	return nil
+}
+
+// placeholderNames returns the names of the tokens referenced by placeholders in the text.
+// Escaped placeholders are skipped.
+func placeholderNames(text, open, close string) []string {
+	names := []string{}
+	pos := 0
+	for {
+		start := strings.Index(text[pos:], open)
+		if start < 0 {
+			return names
+		}
+		start += pos
+		pos = start + len(open)
+		end := findClose(text[pos:], close)
+		if end < 0 || isEscaped(text[:start]) {
+			continue
+		}
+		if p, ok, _ := parsePlaceholder(text[pos : pos+end]); ok {
+			names = append(names, p.name)
+			pos += end + len(close)
+		}
+	}
+}
+
+// hasDataFile reports whether the data file named in a data reference was loaded.
+func hasDataFile(ref string, data map[string]string) bool {
+	parts, _ := dataPath(ref)
+	if strings.HasPrefix(parts[0], "[") {
+		return true
+	}
+	prefix := dataPrefix + "." + parts[0]
+	for name := range data {
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		token Token
+		want  string
+	}{
+		{"required int without default", Token{Name: "PORT", Type: TypeInt, IsRequired: true}, ""},
+		{"required list without default", Token{Name: "ITEMS", Type: TypeList, IsRequired: true}, ""},
+		{"required bool without default", Token{Name: "USE", Type: TypeBool, IsRequired: true}, ""},
+		{"bad int default", Token{Name: "PORT", Type: TypeInt, Default: "80a"}, "whole number"},
+		{"unknown type", Token{Name: "X", Type: "date"}, "unknown token type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &Template{Tokens: []Token{tt.token}, TemplateSourceDir: t.TempDir()}
+			errs := template.Validate()
+			if tt.want == "" && len(errs) > 0 {
+				t.Errorf("Validate() = %v, want no errors", errs)
+			}
+			if tt.want != "" && (len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.want)) {
+				t.Errorf("Validate() = %v, want one error containing %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	template := &Template{
+		Tokens:            []Token{{Name: "NAME", Default: "${PROJECT_DIR|bogus}"}},
+		Derived:           map[string]string{"SLUG": "${NAME|slug|nope}"},
+		TemplateSourceDir: t.TempDir(),
+	}
+	errs := template.Validate()
+	if len(errs) != 2 {
+		t.Fatalf("Validate() = %v, want 2 errors", errs)
+	}
+	for i, want := range []string{`default for NAME: ${PROJECT_DIR|bogus}: unknown filter "bogus"`, `derived token SLUG: ${NAME|slug|nope}: unknown filter "nope"`} {
+		if errs[i].Error() != want {
+			t.Errorf("Validate() error %d = %q, want %q", i, errs[i], want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package templates has file related functions.
+package templates
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Validation is a rule across several tokens, checked after all the tokens are defined.
+type Validation struct {
+	Rule    string   `json:"rule"`
+	Message string   `json:"message"`
+	Tokens  []string `json:"tokens"`
+}
+
+// Display returns the validation's message, or its rule if it has no message.
+func (v Validation) Display() string {
+	if v.Message != "" {
+		return v.Message
+	}
+	return "Rule failed: " + v.Rule
+}
+
+// failedValidations returns the validations whose rules are false for the current token values.
+func (t *Template) failedValidations() ([]Validation, error) {
+	failed := []Validation{}
+	for _, v := range t.Validations {
+		ok, err := evalCondition(v.Rule, t.TokenValues)
+		if err != nil {
+			return nil, fmt.Errorf("validation %q: %w", v.Rule, err)
+		}
+		if !ok {
+			failed = append(failed, v)
+		}
+	}
+	return failed, nil
+}
+
+// validationTokens returns the names of the tokens to ask for again when a validation fails.
+// These are the validation's listed tokens, or else the tokens its rule references,
+// following derived tokens back to the tokens they are made from.
+func (t *Template) validationTokens(v Validation) []string {
+	if len(v.Tokens) > 0 {
+		return v.Tokens
+	}
+	e, err := parseExpr(v.Rule)
+	if err != nil {
+		return nil
+	}
+	open, close := t.contentDelimiters()
+	names := []string{}
+	seen := []string{}
+	var add func(ref string)
+	add = func(ref string) {
+		if slices.Contains(seen, ref) {
+			return
+		}
+		seen = append(seen, ref)
+		if t.findToken(ref) != nil {
+			names = append(names, ref)
+			return
+		}
+		if derived, ok := t.Derived[ref]; ok {
+			for _, name := range placeholderNames(derived, open, close) {
+				add(name)
+			}
+		}
+	}
+	for _, ref := range e.refs() {
+		add(ref)
+	}
+	return names
+}
+
+// validateValidations checks the template's validations for bad rules and unknown token names.
+func (t *Template) validateValidations(defined []string) []error {
+	errs := []error{}
+	for _, v := range t.Validations {
+		errs = append(errs, checkCondition("validation "+v.Rule, v.Rule, defined)...)
+		for _, name := range v.Tokens {
+			if t.findToken(name) == nil {
+				errs = append(errs, fmt.Errorf("validation %s lists %s, which is not a token", v.Rule, name))
+			}
+		}
+	}
+	return errs
+}
+
+// clearDerived removes the values of derived tokens so they can be defined again.
+func (t *Template) clearDerived() {
+	for name := range t.Derived {
+		if t.findToken(name) == nil && !slices.Contains(builtinTokens, name) {
+			delete(t.TokenValues, name)
+		}
+	}
+}